@@ -1,7 +1,7 @@
 // DO NOT EDIT MANUALLY: Generated from https://github.com/spudtrooper/genopts
 package api
 
-//go:generate genopts --prefix=AllFollowers --outfile=allfollowersoptions.go "offset:int" "max:int" "incl:[]string" "start:int" "threads:int" "force"
+//go:generate genopts --prefix=AllFollowers --outfile=allfollowersoptions.go "offset:int" "max:int" "incl:[]string" "start:int" "threads:int" "force" "total:int" "ordered"
 
 type AllFollowersOption func(*allFollowersOptionImpl)
 
@@ -12,6 +12,8 @@ type AllFollowersOptions interface {
 	Start() int
 	Threads() int
 	Force() bool
+	Total() int
+	Ordered() bool
 }
 
 func AllFollowersOffset(offset int) AllFollowersOption {
@@ -80,6 +82,28 @@ func AllFollowersForceFlag(force *bool) AllFollowersOption {
 	}
 }
 
+func AllFollowersTotal(total int) AllFollowersOption {
+	return func(opts *allFollowersOptionImpl) {
+		opts.total = total
+	}
+}
+func AllFollowersTotalFlag(total *int) AllFollowersOption {
+	return func(opts *allFollowersOptionImpl) {
+		opts.total = *total
+	}
+}
+
+func AllFollowersOrdered(ordered bool) AllFollowersOption {
+	return func(opts *allFollowersOptionImpl) {
+		opts.ordered = ordered
+	}
+}
+func AllFollowersOrderedFlag(ordered *bool) AllFollowersOption {
+	return func(opts *allFollowersOptionImpl) {
+		opts.ordered = *ordered
+	}
+}
+
 type allFollowersOptionImpl struct {
 	offset  int
 	max     int
@@ -87,6 +111,8 @@ type allFollowersOptionImpl struct {
 	start   int
 	threads int
 	force   bool
+	total   int
+	ordered bool
 }
 
 func (a *allFollowersOptionImpl) Offset() int    { return a.offset }
@@ -95,6 +121,8 @@ func (a *allFollowersOptionImpl) Incl() []string { return a.incl }
 func (a *allFollowersOptionImpl) Start() int     { return a.start }
 func (a *allFollowersOptionImpl) Threads() int   { return a.threads }
 func (a *allFollowersOptionImpl) Force() bool    { return a.force }
+func (a *allFollowersOptionImpl) Total() int     { return a.total }
+func (a *allFollowersOptionImpl) Ordered() bool  { return a.ordered }
 
 func makeAllFollowersOptionImpl(opts ...AllFollowersOption) *allFollowersOptionImpl {
 	res := &allFollowersOptionImpl{}
@@ -1,7 +1,7 @@
 // DO NOT EDIT MANUALLY: Generated from https://github.com/spudtrooper/genopts
 package api
 
-//go:generate genopts --prefix=AllFollowings --outfile=allfollowingsoptions.go "offset:int" "max:int" "incl:[]string" "start:int" "threads:int" "force"
+//go:generate genopts --prefix=AllFollowings --outfile=allfollowingsoptions.go "offset:int" "max:int" "incl:[]string" "start:int" "threads:int" "force" "total:int" "ordered"
 
 type AllFollowingsOption func(*allFollowingsOptionImpl)
 
@@ -12,6 +12,8 @@ type AllFollowingsOptions interface {
 	Start() int
 	Threads() int
 	Force() bool
+	Total() int
+	Ordered() bool
 }
 
 func AllFollowingsOffset(offset int) AllFollowingsOption {
@@ -80,6 +82,28 @@ func AllFollowingsForceFlag(force *bool) AllFollowingsOption {
 	}
 }
 
+func AllFollowingsTotal(total int) AllFollowingsOption {
+	return func(opts *allFollowingsOptionImpl) {
+		opts.total = total
+	}
+}
+func AllFollowingsTotalFlag(total *int) AllFollowingsOption {
+	return func(opts *allFollowingsOptionImpl) {
+		opts.total = *total
+	}
+}
+
+func AllFollowingsOrdered(ordered bool) AllFollowingsOption {
+	return func(opts *allFollowingsOptionImpl) {
+		opts.ordered = ordered
+	}
+}
+func AllFollowingsOrderedFlag(ordered *bool) AllFollowingsOption {
+	return func(opts *allFollowingsOptionImpl) {
+		opts.ordered = *ordered
+	}
+}
+
 type allFollowingsOptionImpl struct {
 	offset  int
 	max     int
@@ -87,6 +111,8 @@ type allFollowingsOptionImpl struct {
 	start   int
 	threads int
 	force   bool
+	total   int
+	ordered bool
 }
 
 func (a *allFollowingsOptionImpl) Offset() int    { return a.offset }
@@ -95,6 +121,8 @@ func (a *allFollowingsOptionImpl) Incl() []string { return a.incl }
 func (a *allFollowingsOptionImpl) Start() int     { return a.start }
 func (a *allFollowingsOptionImpl) Threads() int   { return a.threads }
 func (a *allFollowingsOptionImpl) Force() bool    { return a.force }
+func (a *allFollowingsOptionImpl) Total() int     { return a.total }
+func (a *allFollowingsOptionImpl) Ordered() bool  { return a.ordered }
 
 func makeAllFollowingsOptionImpl(opts ...AllFollowingsOption) *allFollowingsOptionImpl {
 	res := &allFollowingsOptionImpl{}
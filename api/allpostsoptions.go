@@ -1,7 +1,7 @@
 // DO NOT EDIT MANUALLY: Generated from https://github.com/spudtrooper/genopts
 package api
 
-//go:generate genopts --prefix=AllPosts --outfile=allpostsoptions.go "offset:int" "max:int" "incl:[]string" "start:int" "threads:int" "force"
+//go:generate genopts --prefix=AllPosts --outfile=allpostsoptions.go "offset:int" "max:int" "incl:[]string" "start:int" "threads:int" "force" "total:int" "ordered" "since:IntDate" "known:func(id string) bool"
 
 type AllPostsOption func(*allPostsOptionImpl)
 
@@ -12,6 +12,10 @@ type AllPostsOptions interface {
 	Start() int
 	Threads() int
 	Force() bool
+	Total() int
+	Ordered() bool
+	Since() IntDate
+	Known() func(id string) bool
 }
 
 func AllPostsOffset(offset int) AllPostsOption {
@@ -80,6 +84,50 @@ func AllPostsForceFlag(force *bool) AllPostsOption {
 	}
 }
 
+func AllPostsTotal(total int) AllPostsOption {
+	return func(opts *allPostsOptionImpl) {
+		opts.total = total
+	}
+}
+func AllPostsTotalFlag(total *int) AllPostsOption {
+	return func(opts *allPostsOptionImpl) {
+		opts.total = *total
+	}
+}
+
+func AllPostsOrdered(ordered bool) AllPostsOption {
+	return func(opts *allPostsOptionImpl) {
+		opts.ordered = ordered
+	}
+}
+func AllPostsOrderedFlag(ordered *bool) AllPostsOption {
+	return func(opts *allPostsOptionImpl) {
+		opts.ordered = *ordered
+	}
+}
+
+func AllPostsSince(since IntDate) AllPostsOption {
+	return func(opts *allPostsOptionImpl) {
+		opts.since = since
+	}
+}
+func AllPostsSinceFlag(since *IntDate) AllPostsOption {
+	return func(opts *allPostsOptionImpl) {
+		opts.since = *since
+	}
+}
+
+func AllPostsKnown(known func(id string) bool) AllPostsOption {
+	return func(opts *allPostsOptionImpl) {
+		opts.known = known
+	}
+}
+func AllPostsKnownFlag(known *func(id string) bool) AllPostsOption {
+	return func(opts *allPostsOptionImpl) {
+		opts.known = *known
+	}
+}
+
 type allPostsOptionImpl struct {
 	offset  int
 	max     int
@@ -87,14 +135,22 @@ type allPostsOptionImpl struct {
 	start   int
 	threads int
 	force   bool
+	total   int
+	ordered bool
+	since   IntDate
+	known   func(id string) bool
 }
 
-func (a *allPostsOptionImpl) Offset() int    { return a.offset }
-func (a *allPostsOptionImpl) Max() int       { return a.max }
-func (a *allPostsOptionImpl) Incl() []string { return a.incl }
-func (a *allPostsOptionImpl) Start() int     { return a.start }
-func (a *allPostsOptionImpl) Threads() int   { return a.threads }
-func (a *allPostsOptionImpl) Force() bool    { return a.force }
+func (a *allPostsOptionImpl) Offset() int                 { return a.offset }
+func (a *allPostsOptionImpl) Max() int                    { return a.max }
+func (a *allPostsOptionImpl) Incl() []string              { return a.incl }
+func (a *allPostsOptionImpl) Start() int                  { return a.start }
+func (a *allPostsOptionImpl) Threads() int                { return a.threads }
+func (a *allPostsOptionImpl) Force() bool                 { return a.force }
+func (a *allPostsOptionImpl) Total() int                  { return a.total }
+func (a *allPostsOptionImpl) Ordered() bool               { return a.ordered }
+func (a *allPostsOptionImpl) Since() IntDate              { return a.since }
+func (a *allPostsOptionImpl) Known() func(id string) bool { return a.known }
 
 func makeAllPostsOptionImpl(opts ...AllPostsOption) *allPostsOptionImpl {
 	res := &allPostsOptionImpl{}
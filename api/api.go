@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -37,17 +38,29 @@ type Core struct {
 	xAppAuth  string
 	debug     bool
 	authToken string
+	transport http.RoundTripper
+	baseURL   string
+	limiter   *rateLimiter
+	retry     retryPolicy
 }
 
 func (c *Core) Username() string { return c.username }
 
 func MakeClientFromFlags() (*Core, error) {
+	mOpts := []MakeClientOption{
+		MakeClientDebug(*clientDebug),
+		MakeClientRecordRequests(*recordRequests),
+		MakeClientReplayRequests(*replayRequests),
+		MakeClientRateLimit(*clientRateLimit),
+		MakeClientUploadRateLimit(*clientUploadRateLimit),
+		MakeClientMaxRetries(*clientMaxRetries),
+	}
 	if *user != "" && *token != "" {
-		client := MakeClient(*user, *token, MakeClientDebug(*clientDebug))
+		client := MakeClient(*user, *token, mOpts...)
 		return client, nil
 	}
 	if *userCreds != "" {
-		client, err := MakeClientFromFile(*userCreds, MakeClientDebug(*clientDebug))
+		client, err := MakeClientFromFile(*userCreds, mOpts...)
 		if err != nil {
 			return nil, err
 		}
@@ -56,14 +69,28 @@ func MakeClientFromFlags() (*Core, error) {
 	return nil, errors.Errorf("Must set --user & --token or --creds_file")
 }
 
+// MakeClient returns a client for user. Requests are limited per host to RateLimit and UploadRateLimit
+// requests per second and retried up to MaxRetries times when throttled; zero values use the defaults and
+// negative values turn limiting or retrying off.
 func MakeClient(user, token string, mOpts ...MakeClientOption) *Core {
 	opts := MakeMakeClientOptions(mOpts...)
 	xAppAuth := fmt.Sprintf(`{"user": "%s", "token": "%s"}`, user, token)
+	transport := opts.Transport()
+	if f := opts.ReplayRequests(); f != "" {
+		transport = makeReplayingTransport(f)
+	}
+	if f := opts.RecordRequests(); f != "" {
+		transport = makeRecordingTransport(transport, f)
+	}
 	return &Core{
 		username:  user,
 		xAppAuth:  xAppAuth,
 		debug:     opts.Debug(),
 		authToken: token,
+		transport: transport,
+		baseURL:   strings.TrimSuffix(opts.BaseURL(), "/"),
+		limiter:   makeRateLimiter(opts.RateLimit(), opts.UploadRateLimit()),
+		retry:     makeRetryPolicy(opts.MaxRetries(), opts.MinBackoff(), opts.MaxBackoff()),
 	}
 }
 
@@ -86,18 +113,11 @@ func readCreds(credsFile string) (user string, token string, ret error) {
 }
 
 func MakeClientFromFile(credsFile string, mOpts ...MakeClientOption) (*Core, error) {
-	opts := MakeMakeClientOptions(mOpts...)
 	user, token, err := readCreds(credsFile)
 	if err != nil {
 		return nil, err
 	}
-	xAppAuth := fmt.Sprintf(`{"user": "%s", "token": "%s"}`, user, token)
-	return &Core{
-		username:  user,
-		xAppAuth:  xAppAuth,
-		debug:     opts.Debug(),
-		authToken: token,
-	}, nil
+	return MakeClient(user, token, mOpts...), nil
 }
 
 type param struct {
@@ -117,28 +137,28 @@ func createRoute(base string, ps ...param) string {
 	return fmt.Sprintf("%s?%s", base, strings.Join(ss, "&"))
 }
 
-func (c *Core) get(route string, result interface{}, rOpts ...RequestOption) (*http.Response, error) {
-	return c.request("GET", route, result, nil, rOpts...)
+func (c *Core) get(ctx context.Context, route string, result interface{}, rOpts ...RequestOption) (*http.Response, error) {
+	return c.request(ctx, "GET", route, result, nil, rOpts...)
 }
 
 // TODO: Move body to a RequestOption
-func (c *Core) post(route string, result interface{}, body io.Reader, rOpts ...RequestOption) (*http.Response, error) {
-	return c.request("POST", route, result, body, rOpts...)
+func (c *Core) post(ctx context.Context, route string, result interface{}, body io.Reader, rOpts ...RequestOption) (*http.Response, error) {
+	return c.request(ctx, "POST", route, result, body, rOpts...)
 }
 
 // TODO: Move body to a RequestOption
-func (c *Core) patch(route string, result interface{}, body io.Reader, rOpts ...RequestOption) (*http.Response, error) {
-	return c.request("PATCH", route, result, body, rOpts...)
+func (c *Core) patch(ctx context.Context, route string, result interface{}, body io.Reader, rOpts ...RequestOption) (*http.Response, error) {
+	return c.request(ctx, "PATCH", route, result, body, rOpts...)
 }
 
-func (c *Core) delete(route string, result interface{}, rOpts ...RequestOption) (*http.Response, error) {
-	return c.request("DELETE", route, result, nil, rOpts...)
+func (c *Core) delete(ctx context.Context, route string, result interface{}, rOpts ...RequestOption) (*http.Response, error) {
+	return c.request(ctx, "DELETE", route, result, nil, rOpts...)
 }
 
-func (c *Core) request(method, route string, result interface{}, body io.Reader, rOpts ...RequestOption) (*http.Response, error) {
+func (c *Core) request(ctx context.Context, method, route string, result interface{}, body io.Reader, rOpts ...RequestOption) (*http.Response, error) {
 	opts := MakeRequestOptions(rOpts...)
-	host := or.String(opts.Host(), "api.gettr.com")
-	url := fmt.Sprintf("https://%s/%s", host, route)
+	host := or.String(opts.Host(), apiHost)
+	url := c.url(host, route)
 	if *clientVerbose {
 		// This is to pull off the offsets for debugging and show them to the right of the URL
 		var largeNumbers []string
@@ -162,16 +182,60 @@ func (c *Core) request(method, route string, result interface{}, body io.Reader,
 		}
 	}
 
+	// Buffer the body so it can be sent again on retries.
+	var bodyBytes []byte
+	if body != nil {
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		bodyBytes = b
+	}
+
+	limiter := c.limiter.forHost(host)
+	for attempt := 0; ; attempt++ {
+		if err := limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+		var attemptBody io.Reader
+		if body != nil {
+			attemptBody = bytes.NewReader(bodyBytes)
+		}
+		res, err := c.requestOnce(ctx, method, url, route, result, attemptBody, opts)
+		if err == nil {
+			limiter.succeeded()
+			return res, nil
+		}
+		if !c.retry.shouldRetry(attempt, method, res, err) {
+			return nil, err
+		}
+		wait := retryAfter(res)
+		limiter.throttled(wait)
+		if b := c.retry.backoff(attempt); b > wait {
+			wait = b
+		}
+		if *clientVerbose {
+			log.Printf("retrying %s %s in %v after attempt %d: %v", method, url, wait, attempt+1, err)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// requestOnce sends a single request. The response is returned along with any error once there is one, so
+// that callers can decide whether to retry.
+func (c *Core) requestOnce(ctx context.Context, method, url, route string, result interface{}, body io.Reader, opts RequestOptions) (*http.Response, error) {
 	start := time.Now()
 
-	client := &http.Client{}
+	client := &http.Client{Transport: c.transport}
 	if opts.NoRedirect() {
 		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		}
 	}
 
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
@@ -213,11 +277,8 @@ func (c *Core) request(method, route string, result interface{}, body io.Reader,
 		log.Printf("response <<<\n%s\n>>>", string(data))
 	}
 
-	if c := string(data); strings.Contains(c, "Request unsuccessful") {
-		return nil, errors.Errorf("LIMITED: Request unsuccessful. Incapsula incident")
-	}
-	if c := string(data); strings.Contains(c, "<HTML><HEAD><TITLE>Loading</TITLE>") {
-		return nil, errors.Errorf("LIMITED: Loading instead")
+	if reason := rateLimitedReason(data); reason != "" {
+		return doRes, &RateLimitedError{Reason: reason}
 	}
 
 	if c.debug {
@@ -229,30 +290,33 @@ func (c *Core) request(method, route string, result interface{}, body io.Reader,
 		log.Printf("from route %q have response %s", route, prettyJSON)
 	}
 
+	if doRes.StatusCode >= 400 {
+		if err := parseResponseError(data); err != nil {
+			return doRes, err
+		}
+		return doRes, &StatusError{StatusCode: doRes.StatusCode, Body: string(data)}
+	}
+
 	if len(data) > 0 {
 		if opts.CustomPayload() != nil {
 			if err := json.Unmarshal(data, opts.CustomPayload()); err != nil {
-				return nil, err
+				return doRes, err
 			}
 		} else {
 			var payload struct {
-				ResponseCode string `json:"rc"`
-				Error        struct {
-					Code string `json:"code"`
-					EMsg string `json:"emsg"`
-					Type string `json:"_t"`
-				} `json:"error"`
-				Result interface{}
+				ResponseCode string        `json:"rc"`
+				Error        responseError `json:"error"`
+				Result       interface{}
 			}
 			payload.Result = result
 			if err := json.Unmarshal(data, &payload); err != nil {
-				return nil, err
+				return doRes, err
 			}
 			if *clientVerbose {
 				log.Printf("got response with rc=%s", payload.ResponseCode)
 			}
 			if payload.ResponseCode != "OK" {
-				return nil, errors.Errorf("response error: %+v", payload.Error)
+				return doRes, payload.Error.toError(payload.ResponseCode)
 			}
 		}
 	}
@@ -260,6 +324,39 @@ func (c *Core) request(method, route string, result interface{}, body io.Reader,
 	return doRes, nil
 }
 
+type responseError struct {
+	Code string `json:"code"`
+	EMsg string `json:"emsg"`
+	Type string `json:"_t"`
+}
+
+func (r responseError) toError(rc string) *ResponseError {
+	return &ResponseError{RC: rc, Code: r.Code, EMsg: r.EMsg, Type: r.Type}
+}
+
+// parseResponseError returns the error in data if it's a GETTR error payload, and nil otherwise.
+func parseResponseError(data []byte) error {
+	var payload struct {
+		ResponseCode string        `json:"rc"`
+		Error        responseError `json:"error"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil
+	}
+	if payload.ResponseCode == "" || payload.ResponseCode == "OK" {
+		return nil
+	}
+	return payload.Error.toError(payload.ResponseCode)
+}
+
+// url returns the URL for route on host, or on the base URL if one was given when making the client.
+func (c *Core) url(host, route string) string {
+	if c.baseURL != "" {
+		return fmt.Sprintf("%s/%s", c.baseURL, route)
+	}
+	return fmt.Sprintf("https://%s/%s", host, route)
+}
+
 func prettyPrintJSON(b []byte) (string, error) {
 	b = []byte(strings.TrimSpace(string(b)))
 	if len(b) == 0 {
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
@@ -10,6 +11,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fatih/color"
@@ -76,11 +78,15 @@ func (i IntDate) String() string {
 }
 
 func (c *Core) GetUserInfo(username string) (UserInfo, error) {
+	return c.GetUserInfoContext(context.Background(), username)
+}
+
+func (c *Core) GetUserInfoContext(ctx context.Context, username string) (UserInfo, error) {
 	route := fmt.Sprintf("s/uinf/%s", username)
 	var payload struct {
 		Data UserInfo
 	}
-	if _, err := c.get(route, &payload); err != nil {
+	if _, err := c.get(ctx, route, &payload); err != nil {
 		return UserInfo{}, err
 	}
 	return payload.Data, nil
@@ -105,11 +111,15 @@ type PublicGlobals struct {
 }
 
 func (c *Core) GetPublicGlobals() (*PublicGlobals, error) {
+	return c.GetPublicGlobalsContext(context.Background())
+}
+
+func (c *Core) GetPublicGlobalsContext(ctx context.Context) (*PublicGlobals, error) {
 	route := "u/public_globals"
 	var payload struct {
 		Globals PublicGlobals
 	}
-	if _, err := c.get(route, &payload); err != nil {
+	if _, err := c.get(ctx, route, &payload); err != nil {
 		return nil, err
 	}
 	return &payload.Globals, nil
@@ -126,6 +136,10 @@ type HtInfo struct {
 }
 
 func (c *Core) GetSuggestions(sOpts ...SuggestOption) ([]HtInfo, error) {
+	return c.GetSuggestionsContext(context.Background(), sOpts...)
+}
+
+func (c *Core) GetSuggestionsContext(ctx context.Context, sOpts ...SuggestOption) ([]HtInfo, error) {
 	opts := MakeSuggestOptions(sOpts...)
 	max := or.Int(opts.Max(), 10)
 	route := createRoute("s/hashtag/suggest", param{"max", max})
@@ -135,7 +149,7 @@ func (c *Core) GetSuggestions(sOpts ...SuggestOption) ([]HtInfo, error) {
 	var payload struct {
 		Aux suggestions `json:"aux"`
 	}
-	if _, err := c.get(route, &payload); err != nil {
+	if _, err := c.get(ctx, route, &payload); err != nil {
 		return nil, err
 	}
 	var res []HtInfo
@@ -154,6 +168,7 @@ type PostInfo struct {
 	Dsc     string   `json:"dsc"`
 	Type    string   `json:"_t"`
 	ID      string   `json:"_id"`
+	UID     string   `json:"uid"`
 	Cm      int      `json:"cm"`
 	Lkbpst  int      `json:"lkbpst"`
 	Shbpst  int      `json:"shbpst"`
@@ -173,14 +188,14 @@ func (p PostInfo) Text() string          { return p.Txt }
 func (p PostInfo) Description() string   { return p.Dsc }
 func (p PostInfo) Comments() int         { return p.Cm }
 
-func (c *Core) getPosts(route string, rOpts ...RequestOption) ([]PostInfo, error) {
+func (c *Core) getPosts(ctx context.Context, route string, rOpts ...RequestOption) ([]PostInfo, error) {
 	type posts struct {
 		Posts map[string]PostInfo `json:"post"`
 	}
 	var payload struct {
 		Aux posts `json:"aux"`
 	}
-	if _, err := c.get(route, &payload, rOpts...); err != nil {
+	if _, err := c.get(ctx, route, &payload, rOpts...); err != nil {
 		return nil, err
 	}
 	var res []PostInfo
@@ -191,6 +206,10 @@ func (c *Core) getPosts(route string, rOpts ...RequestOption) ([]PostInfo, error
 }
 
 func (c *Core) GetPosts(username string, pOpts ...PostsOption) ([]PostInfo, error) {
+	return c.GetPostsContext(context.Background(), username, pOpts...)
+}
+
+func (c *Core) GetPostsContext(ctx context.Context, username string, pOpts ...PostsOption) ([]PostInfo, error) {
 	opts := MakePostsOptions(pOpts...)
 	offset := or.Int(opts.Offset(), defaultOffset)
 	max := or.Int(opts.Max(), defaultMax)
@@ -199,10 +218,14 @@ func (c *Core) GetPosts(username string, pOpts ...PostsOption) ([]PostInfo, erro
 	fp := or.String(opts.Fp(), "f_uo")
 	route := createRoute(fmt.Sprintf("u/user/%s/posts", username),
 		param{"offset", offset}, param{"max", max}, param{"dir", dir}, param{"incl", incl}, param{"fp", fp})
-	return c.getPosts(route)
+	return c.getPosts(ctx, route)
 }
 
 func (c *Core) Timeline(pOpts ...TimelineOption) ([]PostInfo, error) {
+	return c.TimelineContext(context.Background(), pOpts...)
+}
+
+func (c *Core) TimelineContext(ctx context.Context, pOpts ...TimelineOption) ([]PostInfo, error) {
 	opts := MakeTimelineOptions(pOpts...)
 	offset := or.Int(opts.Offset(), defaultOffset)
 	max := or.Int(opts.Max(), defaultMax)
@@ -211,10 +234,14 @@ func (c *Core) Timeline(pOpts ...TimelineOption) ([]PostInfo, error) {
 	merge := or.String(opts.Merge(), "shares")
 	route := createRoute(fmt.Sprintf("u/user/%s/timeline", c.username),
 		param{"offset", offset}, param{"max", max}, param{"dir", dir}, param{"incl", incl}, param{"merge", merge})
-	return c.getPosts(route)
+	return c.getPosts(ctx, route)
 }
 
 func (c *Core) LiveNow(pOpts ...LiveNowOption) ([]PostInfo, error) {
+	return c.LiveNowContext(context.Background(), pOpts...)
+}
+
+func (c *Core) LiveNowContext(ctx context.Context, pOpts ...LiveNowOption) ([]PostInfo, error) {
 	opts := MakeLiveNowOptions(pOpts...)
 	offset := or.Int(opts.Offset(), defaultOffset)
 	max := or.Int(opts.Max(), defaultMax)
@@ -224,7 +251,7 @@ func (c *Core) LiveNow(pOpts ...LiveNowOption) ([]PostInfo, error) {
 	lang := or.String(opts.Lang(), "all")
 	route := createRoute("u/posts/livenow",
 		param{"offset", offset}, param{"max", max}, param{"dir", dir}, param{"incl", incl}, param{"merge", merge}, param{"lang", lang})
-	return c.getPosts(route)
+	return c.getPosts(ctx, route)
 }
 
 type CommentInfo struct {
@@ -241,27 +268,47 @@ type CommentInfo struct {
 }
 
 func (c *Core) GetComments(post string, cOpts ...CommentsOption) ([]CommentInfo, error) {
+	return c.GetCommentsContext(context.Background(), post, cOpts...)
+}
+
+func (c *Core) GetCommentsContext(ctx context.Context, post string, cOpts ...CommentsOption) ([]CommentInfo, error) {
+	comments, _, err := c.getComments(ctx, fmt.Sprintf("u/post/%s/comments", post), cOpts...)
+	return comments, err
+}
+
+func (c *Core) GetReplies(comment string, cOpts ...CommentsOption) ([]CommentInfo, error) {
+	return c.GetRepliesContext(context.Background(), comment, cOpts...)
+}
+
+func (c *Core) GetRepliesContext(ctx context.Context, comment string, cOpts ...CommentsOption) ([]CommentInfo, error) {
+	replies, _, err := c.getComments(ctx, fmt.Sprintf("u/comment/%s/comments", comment), cOpts...)
+	return replies, err
+}
+
+// getComments returns a page of the comments under base along with their authors keyed by user ID.
+func (c *Core) getComments(ctx context.Context, base string, cOpts ...CommentsOption) ([]CommentInfo, map[string]UserInfo, error) {
 	opts := MakeCommentsOptions(cOpts...)
 	offset := or.Int(opts.Offset(), defaultOffset)
 	max := or.Int(opts.Max(), defaultMax)
 	dir := or.String(opts.Dir(), defaultDir)
 	incl := or.String(strings.Join(opts.Incl(), "|"), "posts|stats|userinfo|shared|liked")
-	route := createRoute(fmt.Sprintf("u/post/%s/comments", post),
+	route := createRoute(base,
 		param{"offset", offset}, param{"max", max}, param{"dir", dir}, param{"incl", incl})
-	type comments struct {
+	type aux struct {
 		Comments map[string]CommentInfo `json:"cmt"`
+		Uinf     map[string]UserInfo    `json:"uinf"`
 	}
 	var payload struct {
-		Aux comments `json:"aux"`
+		Aux aux `json:"aux"`
 	}
-	if _, err := c.get(route, &payload); err != nil {
-		return nil, err
+	if _, err := c.get(ctx, route, &payload); err != nil {
+		return nil, nil, err
 	}
 	var res []CommentInfo
 	for _, p := range payload.Aux.Comments {
 		res = append(res, p)
 	}
-	return res, nil
+	return res, payload.Aux.Uinf, nil
 }
 
 type ShareInfo struct {
@@ -307,6 +354,10 @@ type PostDetails struct {
 }
 
 func (c *Core) GetPost(post string, pOpts ...PostOption) (PostDetails, error) {
+	return c.GetPostContext(context.Background(), post, pOpts...)
+}
+
+func (c *Core) GetPostContext(ctx context.Context, post string, pOpts ...PostOption) (PostDetails, error) {
 	opts := MakePostOptions(pOpts...)
 	incl := or.String(strings.Join(opts.Incl(), "|"), "posts|stats|userinfo|shared|liked")
 	route := createRoute(fmt.Sprintf("u/post/%s", post), param{"incl", incl})
@@ -318,7 +369,7 @@ func (c *Core) GetPost(post string, pOpts ...PostOption) (PostDetails, error) {
 		Aux  aux      `json:"aux"`
 		Data PostInfo `json:"data"`
 	}
-	if _, err := c.get(route, &payload); err != nil {
+	if _, err := c.get(ctx, route, &payload); err != nil {
 		return PostDetails{}, err
 	}
 	res := PostDetails{
@@ -332,6 +383,10 @@ func (c *Core) GetPost(post string, pOpts ...PostOption) (PostDetails, error) {
 }
 
 func (c *Core) GetMuted(mOpts ...MutedOption) (UserInfos, error) {
+	return c.GetMutedContext(context.Background(), mOpts...)
+}
+
+func (c *Core) GetMutedContext(ctx context.Context, mOpts ...MutedOption) (UserInfos, error) {
 	opts := MakeMutedOptions(mOpts...)
 	offset := or.Int(opts.Offset(), defaultOffset)
 	max := or.Int(opts.Max(), defaultMax)
@@ -343,7 +398,7 @@ func (c *Core) GetMuted(mOpts ...MutedOption) (UserInfos, error) {
 	var payload struct {
 		Aux aux `json:"aux"`
 	}
-	if _, err := c.get(route, &payload); err != nil {
+	if _, err := c.get(ctx, route, &payload); err != nil {
 		return nil, err
 	}
 	var res UserInfos
@@ -354,6 +409,10 @@ func (c *Core) GetMuted(mOpts ...MutedOption) (UserInfos, error) {
 }
 
 func (c *Core) GetFollowings(username string, fOpts ...FollowingsOption) (UserInfos, error) {
+	return c.GetFollowingsContext(context.Background(), username, fOpts...)
+}
+
+func (c *Core) GetFollowingsContext(ctx context.Context, username string, fOpts ...FollowingsOption) (UserInfos, error) {
 	opts := MakeFollowingsOptions(fOpts...)
 	offset := or.Int(opts.Offset(), defaultOffset)
 	max := or.Int(opts.Max(), defaultMax)
@@ -365,7 +424,7 @@ func (c *Core) GetFollowings(username string, fOpts ...FollowingsOption) (UserIn
 	var payload struct {
 		Aux aux `json:"aux"`
 	}
-	if _, err := c.get(route, &payload); err != nil {
+	if _, err := c.get(ctx, route, &payload); err != nil {
 		return nil, err
 	}
 	var res UserInfos
@@ -376,14 +435,22 @@ func (c *Core) GetFollowings(username string, fOpts ...FollowingsOption) (UserIn
 }
 
 func (c *Core) Follow(username string) error {
+	return c.FollowContext(context.Background(), username)
+}
+
+func (c *Core) FollowContext(ctx context.Context, username string) error {
 	route := createRoute(fmt.Sprintf("u/user/%s/follows/%s", c.username, username))
-	if _, err := c.post(route, nil, nil); err != nil {
+	if _, err := c.post(ctx, route, nil, nil); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (c *Core) GetFollowers(username string, fOpts ...FollowersOption) (UserInfos, error) {
+	return c.GetFollowersContext(context.Background(), username, fOpts...)
+}
+
+func (c *Core) GetFollowersContext(ctx context.Context, username string, fOpts ...FollowersOption) (UserInfos, error) {
 	opts := MakeFollowersOptions(fOpts...)
 	offset := or.Int(opts.Offset(), defaultOffset)
 	max := or.Int(opts.Max(), defaultMax)
@@ -395,7 +462,7 @@ func (c *Core) GetFollowers(username string, fOpts ...FollowersOption) (UserInfo
 	var payload struct {
 		Aux aux `json:"aux"`
 	}
-	if _, err := c.get(route, &payload); err != nil {
+	if _, err := c.get(ctx, route, &payload); err != nil {
 		return nil, err
 	}
 	var res UserInfos
@@ -418,6 +485,7 @@ type OffsetPosts struct {
 type clientStatsCollector struct {
 	tag   string
 	start time.Time
+	mu    sync.Mutex
 	durs  []time.Duration
 }
 
@@ -427,6 +495,8 @@ func makeClientStatsCollector(tag string) *clientStatsCollector {
 }
 
 func (c *clientStatsCollector) RecordAndPrint() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	stop := time.Now()
 	dur := stop.Sub(c.start)
 	c.durs = append(c.durs, dur)
@@ -474,6 +544,10 @@ type CreatePostInfo struct {
 func (c CreatePostInfo) URI() string { return postURI(c.ID) }
 
 func (c *Core) CreatePost(text string, cOpts ...CreatePostOption) (CreatePostInfo, error) {
+	return c.CreatePostContext(context.Background(), text, cOpts...)
+}
+
+func (c *Core) CreatePostContext(ctx context.Context, text string, cOpts ...CreatePostOption) (CreatePostInfo, error) {
 	opts := MakeCreatePostOptions(cOpts...)
 	date := int(time.Now().UnixMilli())
 	type aclT struct {
@@ -532,7 +606,7 @@ func (c *Core) CreatePost(text string, cOpts ...CreatePostOption) (CreatePostInf
 	extraHeaders := map[string]string{
 		"Content-Type": "application/x-www-form-urlencoded",
 	}
-	if _, err := c.post(route, &payload, strings.NewReader(data.Encode()), RequestExtraHeaders(extraHeaders)); err != nil {
+	if _, err := c.post(ctx, route, &payload, strings.NewReader(data.Encode()), RequestExtraHeaders(extraHeaders)); err != nil {
 		return CreatePostInfo{}, err
 	}
 	return payload.Data, nil
@@ -548,9 +622,13 @@ type DeletePostInfo struct {
 }
 
 func (c *Core) DeletePost(postID string) (bool, error) {
+	return c.DeletePostContext(context.Background(), postID)
+}
+
+func (c *Core) DeletePostContext(ctx context.Context, postID string) (bool, error) {
 	route := fmt.Sprintf("u/post/%s", postID)
 	var payload bool
-	if _, err := c.delete(route, &payload); err != nil {
+	if _, err := c.delete(ctx, route, &payload); err != nil {
 		return false, err
 	}
 	return payload, nil
@@ -568,6 +646,10 @@ type UploadInfo struct {
 }
 
 func (c *Core) Upload(f string) (UploadInfo, error) {
+	return c.UploadContext(context.Background(), f)
+}
+
+func (c *Core) UploadContext(ctx context.Context, f string) (UploadInfo, error) {
 	extWithNoDot := string(path.Ext(f)[1:])
 	filename := "53d4e55-65f-07ee-ea2f-e3cc0aeec16-base64image." + extWithNoDot
 	filetype := "image/" + extWithNoDot
@@ -597,7 +679,7 @@ func (c *Core) Upload(f string) (UploadInfo, error) {
 			"Upload-Length":   fmt.Sprintf("%d", len(body)),
 		})
 		route := "media/big/upload"
-		res, err := c.post(route, nil, nil, RequestExtraHeaders(extraHeaders), RequestHost("upload.gettr.com"))
+		res, err := c.post(ctx, route, nil, nil, RequestExtraHeaders(extraHeaders), RequestHost("upload.gettr.com"))
 		if err != nil {
 			return UploadInfo{}, err
 		}
@@ -618,7 +700,7 @@ func (c *Core) Upload(f string) (UploadInfo, error) {
 			route = string(route[1:])
 		}
 		res := UploadInfo{}
-		if _, err := c.patch(route, nil, bytes.NewBuffer(body), RequestExtraHeaders(extraHeaders), RequestHost("upload.gettr.com"), RequestCustomPayload(&res)); err != nil {
+		if _, err := c.patch(ctx, route, nil, bytes.NewBuffer(body), RequestExtraHeaders(extraHeaders), RequestHost("upload.gettr.com"), RequestCustomPayload(&res)); err != nil {
 			return UploadInfo{}, err
 		}
 		return res, nil
@@ -628,6 +710,10 @@ func (c *Core) Upload(f string) (UploadInfo, error) {
 type UpdateProfileInfo struct{ UserInfo }
 
 func (c *Core) UpdateProfile(pOpts ...UpdateProfileOption) (UpdateProfileInfo, error) {
+	return c.UpdateProfileContext(context.Background(), pOpts...)
+}
+
+func (c *Core) UpdateProfileContext(ctx context.Context, pOpts ...UpdateProfileOption) (UpdateProfileInfo, error) {
 	opts := MakeUpdateProfileOptions(pOpts...)
 	data := url.Values{}
 	if opts.Description() != "" {
@@ -652,15 +738,19 @@ func (c *Core) UpdateProfile(pOpts ...UpdateProfileOption) (UpdateProfileInfo, e
 	extraHeaders := map[string]string{
 		"Content-Type": "application/x-www-form-urlencoded",
 	}
-	if _, err := c.post(route, &payload, strings.NewReader(data.Encode()), RequestExtraHeaders(extraHeaders)); err != nil {
+	if _, err := c.post(ctx, route, &payload, strings.NewReader(data.Encode()), RequestExtraHeaders(extraHeaders)); err != nil {
 		return UpdateProfileInfo{}, err
 	}
 	return payload.Data, nil
 }
 
 func (c *Core) LikePost(postID string) error {
+	return c.LikePostContext(context.Background(), postID)
+}
+
+func (c *Core) LikePostContext(ctx context.Context, postID string) error {
 	route := fmt.Sprintf("u/user/%s/likes/post/%s", c.username, postID)
-	if _, err := c.post(route, nil, nil); err != nil {
+	if _, err := c.post(ctx, route, nil, nil); err != nil {
 		return err
 	}
 	return nil
@@ -698,6 +788,10 @@ func (c *Core) makeSearchBody(query string, sOpts ...SearchOption) ([]byte, erro
 }
 
 func (c *Core) SearchPosts(query string, sOpts ...SearchOption) ([]PostInfo, error) {
+	return c.SearchPostsContext(context.Background(), query, sOpts...)
+}
+
+func (c *Core) SearchPostsContext(ctx context.Context, query string, sOpts ...SearchOption) ([]PostInfo, error) {
 	body, err := c.makeSearchBody(query, sOpts...)
 	if err != nil {
 		return nil, err
@@ -712,7 +806,7 @@ func (c *Core) SearchPosts(query string, sOpts ...SearchOption) ([]PostInfo, err
 	var payload struct {
 		Aux posts `json:"aux"`
 	}
-	if _, err := c.post(route, &payload, bytes.NewBuffer(body), RequestExtraHeaders(extraHeaders)); err != nil {
+	if _, err := c.post(ctx, route, &payload, bytes.NewBuffer(body), RequestExtraHeaders(extraHeaders)); err != nil {
 		return nil, err
 	}
 	var res []PostInfo
@@ -723,6 +817,10 @@ func (c *Core) SearchPosts(query string, sOpts ...SearchOption) ([]PostInfo, err
 }
 
 func (c *Core) SearchUsers(query string, sOpts ...SearchOption) ([]UserInfo, error) {
+	return c.SearchUsersContext(context.Background(), query, sOpts...)
+}
+
+func (c *Core) SearchUsersContext(ctx context.Context, query string, sOpts ...SearchOption) ([]UserInfo, error) {
 	body, err := c.makeSearchBody(query, sOpts...)
 	if err != nil {
 		return nil, err
@@ -737,7 +835,7 @@ func (c *Core) SearchUsers(query string, sOpts ...SearchOption) ([]UserInfo, err
 	var payload struct {
 		Aux users `json:"aux"`
 	}
-	if _, err := c.post(route, &payload, bytes.NewBuffer(body), RequestExtraHeaders(extraHeaders)); err != nil {
+	if _, err := c.post(ctx, route, &payload, bytes.NewBuffer(body), RequestExtraHeaders(extraHeaders)); err != nil {
 		return nil, err
 	}
 	var res []UserInfo
@@ -748,6 +846,10 @@ func (c *Core) SearchUsers(query string, sOpts ...SearchOption) ([]UserInfo, err
 }
 
 func (c *Core) SharePost(postID string, text string, sOpts ...SharePostOption) error {
+	return c.SharePostContext(context.Background(), postID, text, sOpts...)
+}
+
+func (c *Core) SharePostContext(ctx context.Context, postID string, text string, sOpts ...SharePostOption) error {
 	opts := MakeSharePostOptions(sOpts...)
 	var contentData = struct {
 		Text string `json:"text"`
@@ -769,7 +871,7 @@ func (c *Core) SharePost(postID string, text string, sOpts ...SharePostOption) e
 	extraHeaders := map[string]string{
 		"Content-Type": "application/x-www-form-urlencoded",
 	}
-	if _, err := c.post(route, &payload, strings.NewReader(data.Encode()), RequestExtraHeaders(extraHeaders)); err != nil {
+	if _, err := c.post(ctx, route, &payload, strings.NewReader(data.Encode()), RequestExtraHeaders(extraHeaders)); err != nil {
 		return err
 	}
 	return nil
@@ -789,6 +891,10 @@ type ReplyInfo struct {
 func (c ReplyInfo) URI() string { return postURI(c.ID) }
 
 func (c *Core) Reply(postID string, text string, cOpts ...ReplyOption) (ReplyInfo, error) {
+	return c.ReplyContext(context.Background(), postID, text, cOpts...)
+}
+
+func (c *Core) ReplyContext(ctx context.Context, postID string, text string, cOpts ...ReplyOption) (ReplyInfo, error) {
 	opts := MakeReplyOptions(cOpts...)
 	date := int(time.Now().UnixMilli())
 	type aclT struct {
@@ -849,13 +955,17 @@ func (c *Core) Reply(postID string, text string, cOpts ...ReplyOption) (ReplyInf
 	extraHeaders := map[string]string{
 		"Content-Type": "application/x-www-form-urlencoded",
 	}
-	if _, err := c.post(route, &payload, strings.NewReader(data.Encode()), RequestExtraHeaders(extraHeaders), RequestNoRedirect(true)); err != nil {
+	if _, err := c.post(ctx, route, &payload, strings.NewReader(data.Encode()), RequestExtraHeaders(extraHeaders), RequestNoRedirect(true)); err != nil {
 		return ReplyInfo{}, err
 	}
 	return payload.Data, nil
 }
 
 func (c *Core) Chat(postID string, text string, cOpts ...ChatOption) (bool, error) {
+	return c.ChatContext(context.Background(), postID, text, cOpts...)
+}
+
+func (c *Core) ChatContext(ctx context.Context, postID string, text string, cOpts ...ChatOption) (bool, error) {
 	opts := MakeChatOptions(cOpts...)
 	type messageT struct {
 		Type int    `json:"type"`
@@ -886,19 +996,23 @@ func (c *Core) Chat(postID string, text string, cOpts ...ChatOption) (bool, erro
 		"content-type": `application/json`,
 	}
 	var payload bool
-	if _, err := c.post(route, &payload, bytes.NewBuffer(body), RequestExtraHeaders(extraHeaders)); err != nil {
+	if _, err := c.post(ctx, route, &payload, bytes.NewBuffer(body), RequestExtraHeaders(extraHeaders)); err != nil {
 		return false, err
 	}
 	return payload, nil
 }
 
 func (c *Core) Unfollow(username string) error {
+	return c.UnfollowContext(context.Background(), username)
+}
+
+func (c *Core) UnfollowContext(ctx context.Context, username string) error {
 	route := fmt.Sprintf("u/user/%s/unfollows/%s/", c.username, username)
 	extraHeaders := map[string]string{
 		"content-type": `application/json`,
 	}
 	var payload interface{}
-	if _, err := c.post(route, &payload, nil, RequestExtraHeaders(extraHeaders)); err != nil {
+	if _, err := c.post(ctx, route, &payload, nil, RequestExtraHeaders(extraHeaders)); err != nil {
 		return err
 	}
 	return nil
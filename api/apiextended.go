@@ -1,9 +1,9 @@
 package api
 
 import (
-	"math"
-	"sync"
+	"context"
 
+	"github.com/spudtrooper/gettr/log"
 	"github.com/spudtrooper/goutil/or"
 )
 
@@ -16,262 +16,289 @@ func MakeExtended(c *Core) *Extended {
 	return &Extended{c}
 }
 
-func (c *Extended) GetAllFollowings(username string, fOpts ...FollowingsOption) (UserInfos, error) {
-	opts := MakeFollowingsOptions(fOpts...)
-	max := or.Int(opts.Max(), defaultMax)
-	var res UserInfos
-	for offset := 0; ; offset += max {
-		followings, err := c.GetFollowings(username, FollowingsOffset(offset), FollowingsMax(max))
-		if err != nil {
-			return nil, err
-		}
-		if len(followings) == 0 {
-			break
-		}
-		res = append(res, followings...)
+func (c *Extended) followersPages(username string) PageFunc[UserInfo] {
+	return func(ctx context.Context, offset, max int) ([]UserInfo, error) {
+		return c.GetFollowersContext(ctx, username, FollowersOffset(offset), FollowersMax(max))
 	}
-	return res, nil
 }
 
-func (c *Extended) AllFollowings(username string, f func(offset int, us UserInfos) error, fOpts ...AllFollowingsOption) error {
-	opts := MakeAllFollowingsOptions(fOpts...)
-	max := or.Int(opts.Max(), defaultMax)
-	start := or.Int(opts.Start(), defaultStart)
-	for offset := start; ; offset += max {
-		followings, err := c.GetFollowings(username, FollowingsOffset(offset), FollowingsMax(max))
-		if err != nil {
-			return err
-		}
-		if len(followings) == 0 {
-			break
-		}
-		if err := f(offset, followings); err != nil {
-			return err
-		}
+func (c *Extended) followingsPages(username string) PageFunc[UserInfo] {
+	return func(ctx context.Context, offset, max int) ([]UserInfo, error) {
+		return c.GetFollowingsContext(ctx, username, FollowingsOffset(offset), FollowingsMax(max))
 	}
-	return nil
 }
 
-func (c *Extended) AllFollowingsParallel(username string, fOpts ...AllFollowingsOption) (chan UserInfo, chan error) {
-	opts := MakeAllFollowingsOptions(fOpts...)
-	max := or.Int(opts.Max(), defaultMax)
-	start := or.Int(opts.Start(), defaultStart)
-	threads := or.Int(opts.Threads(), defaultThreads)
+func (c *Extended) postsPages(username string) PageFunc[PostInfo] {
+	return func(ctx context.Context, offset, max int) ([]PostInfo, error) {
+		return c.GetPostsContext(ctx, username, PostsOffset(offset), PostsMax(max))
+	}
+}
 
-	userInfos := make(chan UserInfo)
-	offsets := make(chan int)
-	errs := make(chan error)
+// withClientStats prints request timings for fetch if --client_stats is set.
+func withClientStats[T any](tag string, fetch PageFunc[T]) PageFunc[T] {
+	if !*clientStats {
+		return fetch
+	}
+	col := makeClientStatsCollector(tag)
+	return func(ctx context.Context, offset, max int) ([]T, error) {
+		items, err := fetch(ctx, offset, max)
+		col.RecordAndPrint()
+		return items, err
+	}
+}
 
-	go func() {
-		for offset := start; offset < math.MaxInt; offset += max {
-			offsets <- offset
-		}
-		close(offsets)
-	}()
+func paginatorOptions(start, max, threads, total int, ordered bool) []PaginatorOption {
+	return []PaginatorOption{
+		PaginatorCursor(Cursor{Offset: or.Int(start, defaultStart)}),
+		PaginatorMax(max),
+		PaginatorThreads(threads),
+		PaginatorTotal(total),
+		PaginatorOrdered(ordered),
+	}
+}
 
-	go func() {
-		var wg sync.WaitGroup
-		for i := 0; i < threads; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for offset := range offsets {
-					fs, err := c.GetFollowings(username, FollowingsOffset(offset), FollowingsMax(max))
-					if err != nil {
-						errs <- err
-						break
-					}
-					if len(fs) == 0 {
-						break
-					}
-					for _, u := range fs {
-						userInfos <- u
-					}
-				}
-			}()
-		}
-		wg.Wait()
-		close(userInfos)
-		close(errs)
-	}()
+func (c *Extended) GetAllFollowings(username string, fOpts ...FollowingsOption) (UserInfos, error) {
+	return c.GetAllFollowingsContext(context.Background(), username, fOpts...)
+}
 
-	return userInfos, errs
+func (c *Extended) GetAllFollowingsContext(ctx context.Context, username string, fOpts ...FollowingsOption) (UserInfos, error) {
+	opts := MakeFollowingsOptions(fOpts...)
+	return MakePaginator(c.followingsPages(username), PaginatorMax(opts.Max())).All(ctx)
 }
 
-func (c *Extended) AllFollowersParallel(username string, fOpts ...AllFollowersOption) (chan UserInfo, chan OffsetStrings, chan error) {
-	opts := MakeAllFollowersOptions(fOpts...)
-	max := or.Int(opts.Max(), defaultMax)
-	start := or.Int(opts.Start(), defaultStart)
-	threads := or.Int(opts.Threads(), defaultThreads)
+func (c *Extended) AllFollowings(username string, f func(offset int, us UserInfos) error, fOpts ...AllFollowingsOption) error {
+	return c.AllFollowingsContext(context.Background(), username, f, fOpts...)
+}
 
-	offsets := make(chan int)
-	go func() {
-		for offset := start; offset < math.MaxInt; offset += max {
-			offsets <- offset
-		}
-		close(offsets)
-	}()
+func (c *Extended) AllFollowingsContext(ctx context.Context, username string, f func(offset int, us UserInfos) error, fOpts ...AllFollowingsOption) error {
+	opts := MakeAllFollowingsOptions(fOpts...)
+	p := MakePaginator(c.followingsPages(username), paginatorOptions(opts.Start(), opts.Max(), 0, 0, false)...)
+	return p.Each(ctx, func(page Page[UserInfo]) error {
+		return f(page.Offset, page.Items)
+	})
+}
 
-	userInfos := make(chan UserInfo)
-	userNames := make(chan OffsetStrings)
-	errs := make(chan error)
+func (c *Extended) AllFollowingsParallel(username string, fOpts ...AllFollowingsOption) (chan UserInfo, chan error) {
+	return c.AllFollowingsParallelContext(context.Background(), username, fOpts...)
+}
+
+// AllFollowingsParallelContext streams the users username follows. Once ctx is done the workers stop and
+// both channels are closed, so callers that stop reading early should cancel ctx.
+func (c *Extended) AllFollowingsParallelContext(ctx context.Context, username string, fOpts ...AllFollowingsOption) (chan UserInfo, chan error) {
+	userInfos, userNames, errs := c.AllFollowingParallelContext(ctx, username, fOpts...)
 	go func() {
-		var wg sync.WaitGroup
-		for i := 0; i < threads; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				col := makeClientStatsCollector("AllFollowersParallel")
-				for offset := range offsets {
-					fs, err := c.GetFollowers(username, FollowersOffset(offset), FollowersMax(max))
-					if *clientStats {
-						col.RecordAndPrint()
-					}
-					if err != nil {
-						errs <- err
-						break
-					}
-					if len(fs) == 0 {
-						break
-					}
-					var us []string
-					for _, u := range fs {
-						userInfos <- u
-						us = append(us, u.Username)
-					}
-					userNames <- OffsetStrings{Strings: us, Offset: offset}
-				}
-			}()
+		for range userNames {
 		}
-		wg.Wait()
-		close(userInfos)
-		close(userNames)
-		close(errs)
 	}()
+	return userInfos, errs
+}
+
+func (c *Extended) AllFollowersParallel(username string, fOpts ...AllFollowersOption) (chan UserInfo, chan OffsetStrings, chan error) {
+	return c.AllFollowersParallelContext(context.Background(), username, fOpts...)
+}
 
+// AllFollowersParallelContext streams the followers of username. Once ctx is done the workers stop and all
+// channels are closed, so callers that stop reading early should cancel ctx.
+func (c *Extended) AllFollowersParallelContext(ctx context.Context, username string, fOpts ...AllFollowersOption) (chan UserInfo, chan OffsetStrings, chan error) {
+	opts := MakeAllFollowersOptions(fOpts...)
+	total := opts.Total()
+	if total == 0 {
+		total = c.userTotal(ctx, username, UserInfo.Followers)
+	}
+	p := MakePaginator(withClientStats("AllFollowersParallel", c.followersPages(username)),
+		paginatorOptions(opts.Start(), opts.Max(), opts.Threads(), total, opts.Ordered())...)
+	pages, errs := p.Parallel(ctx)
+	userInfos, userNames := splitUserPages(ctx, pages)
 	return userInfos, userNames, errs
 }
 
 func (c *Extended) AllFollowers(username string, f func(offset int, userInfos UserInfos) error, fOpts ...AllFollowersOption) error {
+	return c.AllFollowersContext(context.Background(), username, f, fOpts...)
+}
+
+func (c *Extended) AllFollowersContext(ctx context.Context, username string, f func(offset int, userInfos UserInfos) error, fOpts ...AllFollowersOption) error {
 	opts := MakeAllFollowersOptions(fOpts...)
-	max := or.Int(opts.Max(), defaultMax)
-	start := or.Int(opts.Start(), defaultStart)
-	for offset := start; ; offset += max {
-		followings, err := c.GetFollowers(username, FollowersOffset(offset), FollowersMax(max))
-		if err != nil {
-			return err
-		}
-		if len(followings) == 0 {
-			break
-		}
-		if err := f(offset, followings); err != nil {
-			return err
-		}
-	}
-	return nil
+	p := MakePaginator(c.followersPages(username), paginatorOptions(opts.Start(), opts.Max(), 0, 0, false)...)
+	return p.Each(ctx, func(page Page[UserInfo]) error {
+		return f(page.Offset, page.Items)
+	})
 }
 
 func (c *Extended) AllFollowingParallel(username string, fOpts ...AllFollowingsOption) (chan UserInfo, chan OffsetStrings, chan error) {
-	opts := MakeAllFollowingsOptions(fOpts...)
-	max := or.Int(opts.Max(), defaultMax)
-	start := or.Int(opts.Start(), defaultStart)
-	threads := or.Int(opts.Threads(), defaultThreads)
+	return c.AllFollowingParallelContext(context.Background(), username, fOpts...)
+}
 
-	userInfos := make(chan UserInfo)
-	userNames := make(chan OffsetStrings)
-	offsets := make(chan int)
-	errs := make(chan error)
+// AllFollowingParallelContext streams the users username follows. Once ctx is done the workers stop and all
+// channels are closed, so callers that stop reading early should cancel ctx.
+func (c *Extended) AllFollowingParallelContext(ctx context.Context, username string, fOpts ...AllFollowingsOption) (chan UserInfo, chan OffsetStrings, chan error) {
+	opts := MakeAllFollowingsOptions(fOpts...)
+	total := opts.Total()
+	if total == 0 {
+		total = c.userTotal(ctx, username, UserInfo.Following)
+	}
+	p := MakePaginator(withClientStats("AllFollowingParallel", c.followingsPages(username)),
+		paginatorOptions(opts.Start(), opts.Max(), opts.Threads(), total, opts.Ordered())...)
+	pages, errs := p.Parallel(ctx)
+	userInfos, userNames := splitUserPages(ctx, pages)
+	return userInfos, userNames, errs
+}
 
-	go func() {
-		for offset := start; offset < math.MaxInt; offset += max {
-			offsets <- offset
+// userTotal returns the count of username's followers or following from their user info, or zero if it can't
+// be looked up.
+func (c *Extended) userTotal(ctx context.Context, username string, count func(UserInfo) int) int {
+	userInfo, err := c.GetUserInfoContext(ctx, username)
+	if err != nil {
+		if *clientVerbose {
+			log.Printf("paginating without a total for %s: %v", username, err)
 		}
-		close(offsets)
-	}()
+		return 0
+	}
+	return count(userInfo)
+}
 
+// splitUserPages sends each user in pages to the first channel and then the page's usernames to the second.
+func splitUserPages(ctx context.Context, pages chan Page[UserInfo]) (chan UserInfo, chan OffsetStrings) {
+	userInfos := make(chan UserInfo)
+	userNames := make(chan OffsetStrings)
 	go func() {
-		var wg sync.WaitGroup
-		for i := 0; i < threads; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				col := makeClientStatsCollector("AllFollowingParallel")
-				for offset := range offsets {
-					fs, err := c.GetFollowings(username, FollowingsOffset(offset), FollowingsMax(max))
-					if *clientStats {
-						col.RecordAndPrint()
-					}
-					if err != nil {
-						errs <- err
-						break
-					}
-					if len(fs) == 0 {
-						break
-					}
-					var us []string
-					for _, u := range fs {
-						userInfos <- u
-						us = append(us, u.Username)
-					}
-					userNames <- OffsetStrings{Strings: us, Offset: offset}
+		defer close(userInfos)
+		defer close(userNames)
+		for p := range pages {
+			var us []string
+			for _, u := range p.Items {
+				select {
+				case userInfos <- u:
+				case <-ctx.Done():
+					return
 				}
-			}()
+				us = append(us, u.Username)
+			}
+			select {
+			case userNames <- OffsetStrings{Strings: us, Offset: p.Offset}:
+			case <-ctx.Done():
+				return
+			}
 		}
-		wg.Wait()
-		close(userInfos)
-		close(userNames)
-		close(errs)
 	}()
-
-	return userInfos, userNames, errs
+	return userInfos, userNames
 }
 
 func (c *Extended) AllPosts(username string, fOpts ...AllPostsOption) (chan OffsetPosts, chan error) {
+	return c.AllPostsContext(context.Background(), username, fOpts...)
+}
+
+// AllPostsContext streams the posts of username a page at a time. Once ctx is done the workers stop and both
+// channels are closed, so callers that stop reading early should cancel ctx.
+//
+// With AllPostsSince or AllPostsKnown it's incremental: pages are read one at a time, newest first, and only
+// posts newer than since and not known are sent. It stops after the first page with a post that isn't.
+func (c *Extended) AllPostsContext(ctx context.Context, username string, fOpts ...AllPostsOption) (chan OffsetPosts, chan error) {
 	opts := MakeAllPostsOptions(fOpts...)
-	max := or.Int(opts.Max(), defaultMax)
-	start := or.Int(opts.Start(), defaultStart)
-	threads := or.Int(opts.Threads(), defaultThreads)
+	if opts.Since() != 0 || opts.Known() != nil {
+		return c.newPosts(ctx, username, opts)
+	}
+	p := MakePaginator(withClientStats("AllPosts", c.postsPages(username)),
+		paginatorOptions(opts.Start(), opts.Max(), opts.Threads(), opts.Total(), opts.Ordered())...)
+	pages, errs := p.Parallel(ctx)
 
 	offsetPosts := make(chan OffsetPosts)
-	offsets := make(chan int)
-	errs := make(chan error)
-
 	go func() {
-		limit := math.MaxInt
-		// limit = 100
-		for offset := start; offset < limit; offset += max {
-			offsets <- offset
+		defer close(offsetPosts)
+		for p := range pages {
+			select {
+			case offsetPosts <- OffsetPosts{Posts: p.Items, Offset: p.Offset}:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(offsets)
 	}()
 
+	return offsetPosts, errs
+}
+
+func (c *Extended) newPosts(ctx context.Context, username string, opts AllPostsOptions) (chan OffsetPosts, chan error) {
+	known := opts.Known()
+	if known == nil {
+		known = func(string) bool { return false }
+	}
+	p := MakePaginator(withClientStats("AllPosts", c.postsPages(username)),
+		paginatorOptions(opts.Start(), opts.Max(), 1, 0, true)...)
+
+	offsetPosts := make(chan OffsetPosts)
+	errs := make(chan error, 1)
 	go func() {
-		var wg sync.WaitGroup
-		for i := 0; i < threads; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				col := makeClientStatsCollector("AllPosts")
-				for offset := range offsets {
-					posts, err := c.GetPosts(username, PostsOffset(offset), PostsMax(max))
-					if *clientStats {
-						col.RecordAndPrint()
-					}
-					if err != nil {
-						errs <- err
-						break
-					}
-					if len(posts) == 0 {
-						break
-					}
-					offsetPosts <- OffsetPosts{Posts: posts, Offset: offset}
+		defer close(offsetPosts)
+		defer close(errs)
+		for {
+			page, ok, err := p.Next(ctx)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if !ok {
+				return
+			}
+			// A page isn't in order, so all of it is checked before stopping.
+			var posts []PostInfo
+			reached := false
+			for _, post := range page.Items {
+				if post.CDate <= opts.Since() || known(post.ID) {
+					reached = true
+				} else {
+					posts = append(posts, post)
 				}
-			}()
+			}
+			if len(posts) > 0 {
+				select {
+				case offsetPosts <- OffsetPosts{Posts: posts, Offset: page.Offset}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if reached {
+				return
+			}
 		}
-		wg.Wait()
-		close(offsetPosts)
-		close(errs)
 	}()
-
 	return offsetPosts, errs
 }
+
+// AllComments returns a paginator over the comments on post.
+func (c *Extended) AllComments(post string, aOpts ...AllCommentsOption) *Paginator[CommentInfo] {
+	opts := MakeAllCommentsOptions(aOpts...)
+	return MakePaginator(func(ctx context.Context, offset, max int) ([]CommentInfo, error) {
+		return c.GetCommentsContext(ctx, post, CommentsOffset(offset), CommentsMax(max), CommentsDir(opts.Dir()), CommentsIncl(opts.Incl()))
+	}, paginatorOptions(opts.Start(), opts.Max(), opts.Threads(), 0, opts.Ordered())...)
+}
+
+// AllMuted returns a paginator over the users the client has muted.
+func (c *Extended) AllMuted(aOpts ...AllMutedOption) *Paginator[UserInfo] {
+	opts := MakeAllMutedOptions(aOpts...)
+	return MakePaginator(func(ctx context.Context, offset, max int) ([]UserInfo, error) {
+		return c.GetMutedContext(ctx, MutedOffset(offset), MutedMax(max), MutedIncl(opts.Incl()))
+	}, paginatorOptions(opts.Start(), opts.Max(), opts.Threads(), 0, opts.Ordered())...)
+}
+
+// AllSearchPosts returns a paginator over the posts matching query.
+func (c *Extended) AllSearchPosts(query string, aOpts ...AllSearchOption) *Paginator[PostInfo] {
+	opts := MakeAllSearchOptions(aOpts...)
+	return MakePaginator(func(ctx context.Context, offset, max int) ([]PostInfo, error) {
+		return c.SearchPostsContext(ctx, query, SearchOffset(offset), SearchMax(max), SearchIncl(opts.Incl()))
+	}, paginatorOptions(opts.Start(), opts.Max(), opts.Threads(), 0, opts.Ordered())...)
+}
+
+// AllSearchUsers returns a paginator over the users matching query.
+func (c *Extended) AllSearchUsers(query string, aOpts ...AllSearchOption) *Paginator[UserInfo] {
+	opts := MakeAllSearchOptions(aOpts...)
+	return MakePaginator(func(ctx context.Context, offset, max int) ([]UserInfo, error) {
+		return c.SearchUsersContext(ctx, query, SearchOffset(offset), SearchMax(max), SearchIncl(opts.Incl()))
+	}, paginatorOptions(opts.Start(), opts.Max(), opts.Threads(), 0, opts.Ordered())...)
+}
+
+// AllTimeline returns a paginator over the client's timeline.
+func (c *Extended) AllTimeline(aOpts ...AllTimelineOption) *Paginator[PostInfo] {
+	opts := MakeAllTimelineOptions(aOpts...)
+	return MakePaginator(func(ctx context.Context, offset, max int) ([]PostInfo, error) {
+		return c.TimelineContext(ctx, TimelineOffset(offset), TimelineMax(max), TimelineDir(opts.Dir()), TimelineIncl(opts.Incl()), TimelineMerge(opts.Merge()))
+	}, paginatorOptions(opts.Start(), opts.Max(), opts.Threads(), 0, opts.Ordered())...)
+}
@@ -0,0 +1,205 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/api/fakegettr"
+)
+
+func TestCoreContextCancelled(t *testing.T) {
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Users: []api.UserInfo{{Username: fakeOther, ID: fakeOther}},
+	})
+	defer s.Close()
+	c := s.MakeClient(fakeUsername)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.GetUserInfoContext(ctx, fakeOther); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetUserInfoContext: expected context.Canceled but got %v", err)
+	}
+	if got := len(s.Requests()); got != 0 {
+		t.Errorf("expected no requests with a cancelled context but got %d", got)
+	}
+}
+
+func TestAllFollowersParallelContextStopsEarly(t *testing.T) {
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Followers: map[string][]string{fakeOther: makeFakeFollowers(500)},
+	})
+	defer s.Close()
+	c := api.MakeExtended(s.MakeClient(fakeUsername))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	userInfos, userNames, errs := c.AllFollowersParallelContext(ctx, fakeOther, api.AllFollowersMax(5), api.AllFollowersThreads(8))
+
+	// Stop reading after the first follower; every channel should still be closed promptly.
+	<-userInfos
+	cancel()
+	timeout := time.After(5 * time.Second)
+	for userInfos != nil || userNames != nil || errs != nil {
+		select {
+		case _, ok := <-userInfos:
+			if !ok {
+				userInfos = nil
+			}
+		case _, ok := <-userNames:
+			if !ok {
+				userNames = nil
+			}
+		case _, ok := <-errs:
+			if !ok {
+				errs = nil
+			}
+		case <-timeout:
+			t.Fatalf("channels weren't closed after cancelling")
+		}
+	}
+	if got, max := len(s.Requests()), 100; got >= max {
+		t.Errorf("expected cancelling to stop requests early but got %d", got)
+	}
+}
+
+func TestAllFollowersParallelStopsAtTotal(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		flg     int
+		maxReqs int
+	}{
+		// One request for the user info, 8 pages of 7 and the empty page that ends the stream.
+		{"exact total", 0, 10},
+		// A stale total only slows things down past it; we still read every follower.
+		{"stale total", 10, 20},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			followers := makeFakeFollowers(53)
+			s := fakegettr.Make(&fakegettr.Fixtures{
+				Users:     []api.UserInfo{{Username: fakeOther, ID: fakeOther, Flg: tc.flg}},
+				Followers: map[string][]string{fakeOther: followers},
+			})
+			defer s.Close()
+			c := api.MakeExtended(s.MakeClient(fakeUsername))
+
+			userInfos, userNames, errs := c.AllFollowersParallel(fakeOther, api.AllFollowersMax(7), api.AllFollowersThreads(8))
+			go func() {
+				for range userNames {
+				}
+			}()
+			var got int
+			for range userInfos {
+				got++
+			}
+			for err := range errs {
+				t.Errorf("AllFollowersParallel: %v", err)
+			}
+			if want := len(followers); got != want {
+				t.Errorf("expected %d followers but got %d", want, got)
+			}
+			if got := len(s.Requests()); got > tc.maxReqs {
+				t.Errorf("expected at most %d requests but got %d", tc.maxReqs, got)
+			}
+		})
+	}
+}
+
+func TestAllFollowersParallelGivenTotal(t *testing.T) {
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Users:     []api.UserInfo{{Username: fakeOther, ID: fakeOther}},
+		Followers: map[string][]string{fakeOther: makeFakeFollowers(20)},
+	})
+	defer s.Close()
+	c := api.MakeExtended(s.MakeClient(fakeUsername))
+
+	userInfos, userNames, errs := c.AllFollowersParallel(fakeOther, api.AllFollowersMax(7), api.AllFollowersTotal(20))
+	go func() {
+		for range userNames {
+		}
+	}()
+	var got int
+	for range userInfos {
+		got++
+	}
+	for err := range errs {
+		t.Errorf("AllFollowersParallel: %v", err)
+	}
+	if want := 20; got != want {
+		t.Errorf("expected %d followers but got %d", want, got)
+	}
+	for _, r := range s.Requests() {
+		if strings.HasPrefix(r.Path, "/s/uinf/") {
+			t.Errorf("expected no user info request with a total but got %s", r.Path)
+		}
+	}
+}
+
+func TestAllPostsIncremental(t *testing.T) {
+	// Newest first, like the real thing.
+	var posts []api.PostInfo
+	for i := 95; i > 0; i-- {
+		posts = append(posts, api.PostInfo{ID: fmt.Sprintf("p%03d", i), CDate: api.IntDate(i * 1000)})
+	}
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Posts: map[string][]api.PostInfo{fakeOther: posts},
+	})
+	defer s.Close()
+	c := api.MakeExtended(s.MakeClient(fakeUsername))
+
+	read := func(aOpts ...api.AllPostsOption) (int, int) {
+		before := len(s.Requests())
+		offsetPosts, errs := c.AllPosts(fakeOther, append(aOpts, api.AllPostsMax(10))...)
+		var got int
+		for ps := range offsetPosts {
+			got += len(ps.Posts)
+		}
+		for err := range errs {
+			t.Errorf("AllPosts: %v", err)
+		}
+		return got, len(s.Requests()) - before
+	}
+
+	if got, requests := read(api.AllPostsSince(70 * 1000)); got != 25 || requests != 3 {
+		t.Errorf("AllPostsSince: want 25 posts from 3 requests, got %d from %d", got, requests)
+	}
+	known := func(id string) bool { return id == "p088" }
+	// The rest of the first page is still read since a page isn't in order.
+	if got, requests := read(api.AllPostsKnown(known)); got != 9 || requests != 1 {
+		t.Errorf("AllPostsKnown: want 9 posts from 1 request, got %d from %d", got, requests)
+	}
+}
+
+func TestAllPostsOrdered(t *testing.T) {
+	var posts []api.PostInfo
+	for i := 0; i < 95; i++ {
+		posts = append(posts, api.PostInfo{ID: fmt.Sprintf("p%03d", i)})
+	}
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Posts: map[string][]api.PostInfo{fakeOther: posts},
+	})
+	defer s.Close()
+	c := api.MakeExtended(s.MakeClient(fakeUsername))
+
+	offsetPosts, errs := c.AllPosts(fakeOther, api.AllPostsMax(10), api.AllPostsThreads(6), api.AllPostsOrdered(true))
+	var offsets []int
+	var got int
+	for ps := range offsetPosts {
+		offsets = append(offsets, ps.Offset)
+		got += len(ps.Posts)
+	}
+	for err := range errs {
+		t.Errorf("AllPosts: %v", err)
+	}
+	if want := len(posts); got != want {
+		t.Errorf("expected %d posts but got %d", want, got)
+	}
+	for i, o := range offsets {
+		if want := i * 10; o != want {
+			t.Fatalf("expected pages in order but got offsets %v", offsets)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/goutil/flags"
+)
+
+var (
+	recordRequests = flags.String("record_requests", "JSONL file to which every request and its response is appended")
+	replayRequests = flags.String("replay_requests", "JSONL file written by --record_requests from which to answer requests instead of the network")
+)
+
+// Headers that carry credentials and are never written to a cassette.
+var redactedHeaders = []string{"x-app-auth", "Authorization"}
+
+const redacted = "REDACTED"
+
+// CassetteEntry is one line of a cassette written with --record_requests. Bodies are kept as bytes, and so
+// are base64 in the JSON, so that binary bodies survive.
+type CassetteEntry struct {
+	Method          string            `json:"method"`
+	Host            string            `json:"host"`
+	Route           string            `json:"route"`
+	RequestHeaders  map[string]string `json:"request_headers"`
+	RequestBody     []byte            `json:"request_body,omitempty"`
+	StatusCode      int               `json:"status_code"`
+	ResponseHeaders map[string]string `json:"response_headers"`
+	ResponseBody    []byte            `json:"response_body"`
+}
+
+func cassetteRoute(req *http.Request) string {
+	return strings.TrimPrefix(req.URL.RequestURI(), "/")
+}
+
+func flattenHeaders(h http.Header) map[string]string {
+	res := map[string]string{}
+	for k, vs := range h {
+		res[k] = strings.Join(vs, ",")
+	}
+	return res
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+// recordingTransport passes requests to next and appends each exchange to a cassette file.
+type recordingTransport struct {
+	next http.RoundTripper
+	file string
+	mu   sync.Mutex
+}
+
+func makeRecordingTransport(next http.RoundTripper, file string) *recordingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recordingTransport{next: next, file: file}
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+
+	reqHeaders := flattenHeaders(req.Header)
+	for _, h := range redactedHeaders {
+		for k := range reqHeaders {
+			if strings.EqualFold(k, h) {
+				reqHeaders[k] = redacted
+			}
+		}
+	}
+	entry := CassetteEntry{
+		Method:          req.Method,
+		Host:            req.URL.Host,
+		Route:           cassetteRoute(req),
+		RequestHeaders:  reqHeaders,
+		RequestBody:     reqBody,
+		StatusCode:      res.StatusCode,
+		ResponseHeaders: flattenHeaders(res.Header),
+		ResponseBody:    resBody,
+	}
+	if err := t.write(entry); err != nil {
+		return nil, errors.Errorf("recording %s %s: %v", req.Method, entry.Route, err)
+	}
+	return res, nil
+}
+
+func (t *recordingTransport) write(entry CassetteEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f, err := os.OpenFile(t.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+// replayingTransport answers requests from a cassette without touching the network. Requests are matched
+// on method, route and body, falling back to method and route; repeated requests are answered with the
+// recorded responses in order, and the last one once those run out.
+type replayingTransport struct {
+	file    string
+	once    sync.Once
+	loadErr error
+	mu      sync.Mutex
+	entries map[string][]CassetteEntry
+}
+
+func makeReplayingTransport(file string) *replayingTransport {
+	return &replayingTransport{file: file}
+}
+
+func replayKey(method, route string, body []byte, withBody bool) string {
+	if !withBody {
+		return method + " " + route
+	}
+	return method + " " + route + " " + string(body)
+}
+
+func (t *replayingTransport) load() error {
+	f, err := os.Open(t.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	t.entries = map[string][]CassetteEntry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e CassetteEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return errors.Errorf("reading %s: %v", t.file, err)
+		}
+		for _, k := range []string{replayKey(e.Method, e.Route, e.RequestBody, true), replayKey(e.Method, e.Route, nil, false)} {
+			t.entries[k] = append(t.entries[k], e)
+		}
+	}
+	return scanner.Err()
+}
+
+func (t *replayingTransport) next(keys ...string) (CassetteEntry, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, k := range keys {
+		es := t.entries[k]
+		if len(es) == 0 {
+			continue
+		}
+		e := es[0]
+		if len(es) > 1 {
+			t.entries[k] = es[1:]
+		}
+		return e, true
+	}
+	return CassetteEntry{}, false
+}
+
+func (t *replayingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.once.Do(func() { t.loadErr = t.load() })
+	if t.loadErr != nil {
+		return nil, t.loadErr
+	}
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	route := cassetteRoute(req)
+	e, ok := t.next(replayKey(req.Method, route, reqBody, true), replayKey(req.Method, route, nil, false))
+	if !ok {
+		return nil, errors.Errorf("no recorded response in %s for %s %s", t.file, req.Method, route)
+	}
+	header := http.Header{}
+	for k, v := range e.ResponseHeaders {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		Status:        http.StatusText(e.StatusCode),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.ResponseBody)),
+		ContentLength: int64(len(e.ResponseBody)),
+		Request:       req,
+	}, nil
+}
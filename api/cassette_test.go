@@ -0,0 +1,109 @@
+package api_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/api/fakegettr"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	cassette := path.Join(t.TempDir(), "cassette.jsonl")
+
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Users: []api.UserInfo{
+			{Username: fakeOther, Nickname: "Other", ID: fakeOther},
+		},
+		Posts: map[string][]api.PostInfo{
+			fakeOther: {{ID: "p1", Txt: "first post", Lkbpst: 3}},
+		},
+		Comments: map[string][]api.CommentInfo{
+			"p1": {{ID: "c1", Text: "a comment", UID: fakeUsername, PID: "p1", PUID: fakeOther}},
+		},
+	})
+
+	type results struct {
+		post     api.PostDetails
+		comments []api.CommentInfo
+		users    []api.UserInfo
+	}
+	run := func(c *api.Core) results {
+		var res results
+		var err error
+		if res.post, err = c.GetPost("p1"); err != nil {
+			t.Fatalf("GetPost: %v", err)
+		}
+		if res.comments, err = c.GetComments("p1"); err != nil {
+			t.Fatalf("GetComments: %v", err)
+		}
+		if res.users, err = c.SearchUsers("other"); err != nil {
+			t.Fatalf("SearchUsers: %v", err)
+		}
+		return res
+	}
+
+	recorded := run(s.MakeClient(fakeUsername, api.MakeClientRecordRequests(cassette)))
+	s.Close()
+
+	b, err := ioutil.ReadFile(cassette)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if got, want := strings.Count(string(b), "\n"), 3; got != want {
+		t.Errorf("expected %d recorded requests but got %d", want, got)
+	}
+	if strings.Contains(string(b), "fake-token") {
+		t.Errorf("cassette contains credentials: %s", b)
+	}
+
+	replayed := run(api.MakeClient(fakeUsername, "fake-token", api.MakeClientReplayRequests(cassette)))
+	if !reflect.DeepEqual(recorded, replayed) {
+		t.Errorf("replayed != recorded: %+v %+v", replayed, recorded)
+	}
+	if got, want := replayed.post.Lkbpst, 3; got != want {
+		t.Errorf("GetPost: got %d likes but expected %d", got, want)
+	}
+
+	if _, err := api.MakeClient(fakeUsername, "fake-token", api.MakeClientReplayRequests(cassette)).GetPost("p2"); err == nil {
+		t.Errorf("GetPost: expected an error for an unrecorded request")
+	}
+}
+
+func TestCassetteBinaryBody(t *testing.T) {
+	cassette := path.Join(t.TempDir(), "cassette.jsonl")
+	body := []byte{0x1f, 0x8b, 0xff, 0xfe, 0x00, 'x', 0xc3}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(body)
+	}))
+	defer s.Close()
+
+	// The body isn't JSON, so it comes back whole in the error.
+	statusBody := func(c *api.Core) []byte {
+		_, err := c.GetPost("p1")
+		var statusErr *api.StatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("GetPost: want a StatusError, got %v", err)
+		}
+		return []byte(statusErr.Body)
+	}
+
+	recorded := statusBody(api.MakeClient(fakeUsername, "fake-token",
+		api.MakeClientBaseURL(s.URL), api.MakeClientRecordRequests(cassette)))
+	if !bytes.Equal(recorded, body) {
+		t.Fatalf("recorded body: got %v, want %v", recorded, body)
+	}
+	replayed := statusBody(api.MakeClient(fakeUsername, "fake-token", api.MakeClientReplayRequests(cassette)))
+	if !bytes.Equal(replayed, body) {
+		t.Errorf("replayed body: got %v, want %v", replayed, body)
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/spudtrooper/goutil/or"
+)
+
+const defaultCommentThreadThreads = 20
+
+// CommentNode is a comment, its author and the replies to it.
+type CommentNode struct {
+	Comment CommentInfo
+	Author  UserInfo
+	Replies []*CommentNode
+}
+
+// CommentThread is every comment on a post, with replies nested under the comment they reply to. Comments
+// at each level are oldest first.
+type CommentThread struct {
+	PostID   string
+	Comments []*CommentNode
+}
+
+// Count returns the number of comments in the thread, including replies.
+func (t *CommentThread) Count() int {
+	var count func(nodes []*CommentNode) int
+	count = func(nodes []*CommentNode) int {
+		res := len(nodes)
+		for _, n := range nodes {
+			res += count(n.Replies)
+		}
+		return res
+	}
+	return count(t.Comments)
+}
+
+func (c *Extended) CommentThread(postID string, cOpts ...CommentThreadOption) (*CommentThread, error) {
+	return c.CommentThreadContext(context.Background(), postID, cOpts...)
+}
+
+// CommentThreadContext reads every comment on postID and, a level at a time, the replies to those comments.
+// MaxDepth limits how many levels are read, e.g. 1 for only the comments on the post; zero means no limit.
+// Replies are read with GetReplies, whose route hasn't been confirmed against GETTR, so only the first level
+// is known to be complete.
+func (c *Extended) CommentThreadContext(ctx context.Context, postID string, cOpts ...CommentThreadOption) (*CommentThread, error) {
+	opts := MakeCommentThreadOptions(cOpts...)
+	max := or.Int(opts.Max(), defaultMax)
+	threads := or.Int(opts.Threads(), defaultCommentThreadThreads)
+
+	comments, err := c.commentNodes(ctx, fmt.Sprintf("u/post/%s/comments", postID), max)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	level := dedupeCommentNodes(comments, seen)
+	res := &CommentThread{PostID: postID, Comments: level}
+	for depth := 1; len(level) > 0 && (opts.MaxDepth() == 0 || depth < opts.MaxDepth()); depth++ {
+		if err := c.fetchReplies(ctx, level, max, threads); err != nil {
+			return nil, err
+		}
+		var next []*CommentNode
+		for _, n := range level {
+			n.Replies = dedupeCommentNodes(n.Replies, seen)
+			next = append(next, n.Replies...)
+		}
+		level = next
+	}
+	return res, nil
+}
+
+// dedupeCommentNodes drops the nodes already in seen, so that a reply listed twice can't make the tree cyclic.
+func dedupeCommentNodes(nodes []*CommentNode, seen map[string]bool) []*CommentNode {
+	var res []*CommentNode
+	for _, n := range nodes {
+		if seen[n.Comment.ID] {
+			continue
+		}
+		seen[n.Comment.ID] = true
+		res = append(res, n)
+	}
+	return res
+}
+
+// fetchReplies sets the replies of every node in nodes using up to threads concurrent requests.
+func (c *Extended) fetchReplies(ctx context.Context, nodes []*CommentNode, max, threads int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan bool, threads)
+	for _, n := range nodes {
+		n := n
+		select {
+		case sem <- true:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			replies, err := c.commentNodes(ctx, fmt.Sprintf("u/comment/%s/comments", n.Comment.ID), max)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+				return
+			}
+			n.Replies = replies
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// commentNodes reads every comment under base, oldest first, along with its author.
+func (c *Extended) commentNodes(ctx context.Context, base string, max int) ([]*CommentNode, error) {
+	authors := map[string]UserInfo{}
+	comments, err := MakePaginator(func(ctx context.Context, offset, max int) ([]CommentInfo, error) {
+		comments, uinf, err := c.getComments(ctx, base, CommentsOffset(offset), CommentsMax(max))
+		for id, u := range uinf {
+			authors[id] = u
+		}
+		return comments, err
+	}, PaginatorMax(max)).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if comments[i].CDate != comments[j].CDate {
+			return comments[i].CDate < comments[j].CDate
+		}
+		return comments[i].ID < comments[j].ID
+	})
+	var res []*CommentNode
+	for _, comment := range comments {
+		res = append(res, &CommentNode{Comment: comment, Author: authors[comment.UID]})
+	}
+	return res, nil
+}
@@ -0,0 +1,82 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/api/fakegettr"
+)
+
+func TestCommentThread(t *testing.T) {
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Users: []api.UserInfo{
+			{Username: fakeUsername, ID: fakeUsername, Nickname: "Fake"},
+			{Username: fakeOther, ID: fakeOther, Nickname: "Other"},
+		},
+		Comments: map[string][]api.CommentInfo{
+			"p1": {
+				{ID: "c2", CDate: 2, UID: fakeOther, PID: "p1"},
+				{ID: "c1", CDate: 1, UID: fakeUsername, PID: "p1"},
+			},
+			"c1": {
+				{ID: "r1", CDate: 3, UID: fakeOther, PID: "p1"},
+				{ID: "r2", CDate: 4, UID: fakeUsername, PID: "p1"},
+			},
+			"r1": {
+				{ID: "rr1", CDate: 5, UID: fakeUsername, PID: "p1"},
+			},
+		},
+	})
+	defer s.Close()
+	c := api.MakeExtended(s.MakeClient(fakeUsername))
+
+	thread, err := c.CommentThread("p1", api.CommentThreadMax(1))
+	if err != nil {
+		t.Fatalf("CommentThread: %v", err)
+	}
+	if got, want := thread.Count(), 5; got != want {
+		t.Errorf("expected %d comments but got %d", want, got)
+	}
+	if len(thread.Comments) != 2 {
+		t.Fatalf("expected 2 top-level comments but got %d", len(thread.Comments))
+	}
+	c1 := thread.Comments[0]
+	if got, want := c1.Comment.ID, "c1"; got != want {
+		t.Errorf("expected the oldest comment %q first but got %q", want, got)
+	}
+	if got, want := c1.Author.Nickname, "Fake"; got != want {
+		t.Errorf("expected author %q but got %q", want, got)
+	}
+	if len(c1.Replies) != 2 || c1.Replies[0].Comment.ID != "r1" || c1.Replies[0].Author.Nickname != "Other" {
+		t.Fatalf("unexpected replies to c1: %+v", c1.Replies)
+	}
+	if r1 := c1.Replies[0]; len(r1.Replies) != 1 || r1.Replies[0].Comment.ID != "rr1" {
+		t.Errorf("unexpected replies to r1: %+v", r1.Replies)
+	}
+
+	shallow, err := c.CommentThread("p1", api.CommentThreadMaxDepth(2))
+	if err != nil {
+		t.Fatalf("CommentThread: %v", err)
+	}
+	if got, want := shallow.Count(), 4; got != want {
+		t.Errorf("expected %d comments with MaxDepth(2) but got %d", want, got)
+	}
+}
+
+func TestCommentThreadCancelled(t *testing.T) {
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Comments: map[string][]api.CommentInfo{
+			"p1": {{ID: "c1", UID: fakeOther, PID: "p1"}, {ID: "c2", UID: fakeOther, PID: "p1"}},
+		},
+	})
+	defer s.Close()
+	c := api.MakeExtended(s.MakeClient(fakeUsername))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.CommentThreadContext(ctx, "p1", api.CommentThreadThreads(1)); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled but got %v", err)
+	}
+}
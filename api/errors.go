@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Conditions callers can test for with errors.Is on any error returned from Core.
+var (
+	// ErrRateLimited is returned when GETTR serves an Incapsula block or "Loading" page instead of a response.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrLimitExceeded is returned when the account has hit a GETTR usage meter, e.g. for follows or likes.
+	ErrLimitExceeded = errors.New("limit exceeded")
+	// ErrUserDeleted is returned when requesting a user that has been deleted.
+	ErrUserDeleted = errors.New("user deleted")
+	// ErrNotFound is returned when the requested user, post or route doesn't exist.
+	ErrNotFound = errors.New("not found")
+)
+
+// ResponseError is an error payload returned by GETTR with a response code other than OK.
+type ResponseError struct {
+	RC   string
+	Code string
+	EMsg string
+	Type string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("response error: rc=%s code=%s emsg=%q _t=%s", e.RC, e.Code, e.EMsg, e.Type)
+}
+
+func (e *ResponseError) Is(target error) bool {
+	switch target {
+	case ErrLimitExceeded:
+		return e.Code == "E_METER_LIMIT_EXCEEDED"
+	case ErrUserDeleted:
+		return e.Code == "E_USER_DELETED" || strings.Contains(e.EMsg, "user already deleted")
+	case ErrNotFound:
+		return strings.HasSuffix(e.Code, "NOTFOUND") || strings.HasSuffix(e.Code, "NOT_FOUND")
+	}
+	return false
+}
+
+// RateLimitedError is returned instead of a response when GETTR pushes back; it matches ErrRateLimited.
+type RateLimitedError struct {
+	Reason string
+}
+
+func (e *RateLimitedError) Error() string { return fmt.Sprintf("LIMITED: %s", e.Reason) }
+
+func (e *RateLimitedError) Is(target error) bool { return target == ErrRateLimited }
+
+// StatusError is returned for an HTTP error status whose body isn't a GETTR error payload.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d %s: %q", e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// rateLimitedReason returns why data is a rate-limiting page rather than a response, or "" if it isn't one.
+func rateLimitedReason(data []byte) string {
+	c := string(data)
+	if strings.Contains(c, "Request unsuccessful") {
+		return "Request unsuccessful. Incapsula incident"
+	}
+	if strings.Contains(c, "<HTML><HEAD><TITLE>Loading</TITLE>") {
+		return "Loading instead"
+	}
+	return ""
+}
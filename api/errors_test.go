@@ -0,0 +1,46 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/api/fakegettr"
+)
+
+func TestErrors(t *testing.T) {
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Users:        []api.UserInfo{{Username: fakeOther, ID: fakeOther}},
+		DeletedUsers: []string{"deleted"},
+	})
+	defer s.Close()
+	c := s.MakeClient(fakeUsername, api.MakeClientMaxRetries(-1))
+
+	_, err := c.GetUserInfo("nobody")
+	if !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("GetUserInfo(nobody): expected ErrNotFound but got %v", err)
+	}
+	var responseErr *api.ResponseError
+	if !errors.As(err, &responseErr) {
+		t.Fatalf("GetUserInfo(nobody): expected a *ResponseError but got %T", err)
+	}
+	if got, want := responseErr.Code, "E_USER_NOTFOUND"; got != want {
+		t.Errorf("GetUserInfo(nobody): got code %q but expected %q", got, want)
+	}
+
+	if _, err := c.GetUserInfo("deleted"); !errors.Is(err, api.ErrUserDeleted) {
+		t.Errorf("GetUserInfo(deleted): expected ErrUserDeleted but got %v", err)
+	}
+
+	s.RateLimitNext(1)
+	_, err = c.GetUserInfo(fakeOther)
+	if !errors.Is(err, api.ErrRateLimited) {
+		t.Errorf("GetUserInfo: expected ErrRateLimited but got %v", err)
+	}
+	if errors.Is(err, api.ErrNotFound) {
+		t.Errorf("GetUserInfo: rate limited error shouldn't be ErrNotFound")
+	}
+	if _, err := c.GetUserInfo(fakeOther); err != nil {
+		t.Errorf("GetUserInfo: expected success after rate limiting but got %v", err)
+	}
+}
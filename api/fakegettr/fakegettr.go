@@ -0,0 +1,552 @@
+// Package fakegettr is an in-process fake of the GETTR API that serves canned fixtures, so that
+// clients in package api can be exercised without the live service.
+package fakegettr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spudtrooper/gettr/api"
+)
+
+// Fixtures is the data served by the fake. All maps are keyed by username, except Comments which is
+// keyed by post ID, or by comment ID for replies. Lists are paged in the order they are given, but like the
+// real API each page is a map, so order within a page isn't kept. Users without follower or following
+// counts get the lengths of their lists.
+type Fixtures struct {
+	Users        []api.UserInfo
+	DeletedUsers []string
+	Followers    map[string][]string
+	Following    map[string][]string
+	Posts        map[string][]api.PostInfo
+	Comments     map[string][]api.CommentInfo
+}
+
+func ReadFixtures(file string) (*Fixtures, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var res Fixtures
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Request is a request received by the fake.
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+}
+
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	users       map[string]api.UserInfo
+	deleted     map[string]bool
+	rateLimited int
+	failures    int
+	failStatus  int
+	followers   map[string][]string
+	following   map[string][]string
+	posts       map[string][]api.PostInfo
+	postOwners  map[string]string
+	comments    map[string][]api.CommentInfo
+	uploads     map[string][]byte
+	requests    []Request
+	nextID      int
+}
+
+// Make starts a fake serving fixtures. Callers must call Close when done.
+func Make(fixtures *Fixtures) *Server {
+	if fixtures == nil {
+		fixtures = &Fixtures{}
+	}
+	s := &Server{
+		users:      map[string]api.UserInfo{},
+		deleted:    map[string]bool{},
+		followers:  map[string][]string{},
+		following:  map[string][]string{},
+		posts:      map[string][]api.PostInfo{},
+		postOwners: map[string]string{},
+		comments:   map[string][]api.CommentInfo{},
+		uploads:    map[string][]byte{},
+	}
+	for _, u := range fixtures.Users {
+		s.users[u.Username] = u
+	}
+	for _, u := range fixtures.DeletedUsers {
+		s.deleted[u] = true
+	}
+	for u, fs := range fixtures.Followers {
+		s.followers[u] = append([]string{}, fs...)
+	}
+	for u, fs := range fixtures.Following {
+		s.following[u] = append([]string{}, fs...)
+	}
+	for u, ps := range fixtures.Posts {
+		s.posts[u] = append([]api.PostInfo{}, ps...)
+		for _, p := range ps {
+			s.postOwners[p.ID] = u
+		}
+	}
+	for p, cs := range fixtures.Comments {
+		s.comments[p] = append([]api.CommentInfo{}, cs...)
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// MakeClient returns a client for username that talks to this fake.
+func (s *Server) MakeClient(username string, mOpts ...api.MakeClientOption) *api.Core {
+	mOpts = append([]api.MakeClientOption{
+		api.MakeClientBaseURL(s.URL),
+		api.MakeClientTransport(s.Client().Transport),
+	}, mOpts...)
+	return api.MakeClient(username, "fake-token", mOpts...)
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request{}, s.requests...)
+}
+
+// Upload returns the bytes uploaded to the location ori returned from an upload.
+func (s *Server) Upload(ori string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.uploads[strings.TrimPrefix(ori, "/")]
+	return b, ok
+}
+
+// RateLimitNext makes the fake answer the next n requests with an Incapsula block page.
+func (s *Server) RateLimitNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimited = n
+}
+
+// FailNext makes the fake answer the next n requests with status and no GETTR payload.
+func (s *Server) FailNext(n, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+	s.failStatus = status
+}
+
+const incapsulaPage = `<html style="height:100%"><head><META NAME="ROBOTS" CONTENT="NOINDEX, NOFOLLOW"></head>` +
+	`<body>Request unsuccessful. Incapsula incident ID: 0-0</body></html>`
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery})
+	limited := s.rateLimited > 0
+	if limited {
+		s.rateLimited--
+	}
+	failStatus := 0
+	if !limited && s.failures > 0 {
+		s.failures--
+		failStatus = s.failStatus
+	}
+	s.mu.Unlock()
+
+	if limited {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(incapsulaPage))
+		return
+	}
+	if failStatus != 0 {
+		http.Error(w, http.StatusText(failStatus), failStatus)
+		return
+	}
+
+	if r.Header.Get("x-app-auth") == "" {
+		writeError(w, "E_AUTH", "missing x-app-auth")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	match := func(method string, pattern ...string) bool {
+		if r.Method != method || len(parts) != len(pattern) {
+			return false
+		}
+		for i, p := range pattern {
+			if p != "*" && p != parts[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case match("GET", "s", "uinf", "*"):
+		s.handleUserInfo(w, parts[2])
+	case match("GET", "u", "user", "*", "followers"):
+		s.handleUsers(w, r, s.followers, parts[2])
+	case match("GET", "u", "user", "*", "followings"):
+		s.handleUsers(w, r, s.following, parts[2])
+	case match("GET", "u", "user", "*", "posts"):
+		s.handlePosts(w, r, parts[2])
+	case match("GET", "u", "post", "*"):
+		s.handlePost(w, parts[2])
+	case match("DELETE", "u", "post", "*"):
+		s.handleDeletePost(w, parts[2])
+	case match("GET", "u", "post", "*", "comments"):
+		s.handleComments(w, r, parts[2])
+	case match("GET", "u", "comment", "*", "comments"):
+		s.handleComments(w, r, parts[2])
+	case match("POST", "u", "post"):
+		s.handleCreatePost(w, r)
+	case match("POST", "u", "posts", "srch", "phrase"):
+		s.handleSearchPosts(w, r)
+	case match("POST", "u", "users", "srch", "phrase"):
+		s.handleSearchUsers(w, r)
+	case match("POST", "media", "big", "upload"):
+		s.handleStartUpload(w)
+	case match("PATCH", "media", "big", "upload", "*"):
+		s.handleFinishUpload(w, r, parts[3])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeOK(w http.ResponseWriter, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"rc":     "OK",
+		"result": result,
+	})
+}
+
+func writeError(w http.ResponseWriter, code, emsg string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"rc": "ERR",
+		"error": map[string]string{
+			"code": code,
+			"emsg": emsg,
+			"_t":   "errinfo",
+		},
+	})
+}
+
+func page(r *http.Request, n int) (int, int) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	max, err := strconv.Atoi(r.URL.Query().Get("max"))
+	if err != nil || max <= 0 {
+		max = 20
+	}
+	if offset > n {
+		offset = n
+	}
+	end := offset + max
+	if end > n {
+		end = n
+	}
+	return offset, end
+}
+
+// userInfo returns the fixture for username or a minimal one if there isn't one. Must be called with mu held.
+func (s *Server) userInfo(username string) api.UserInfo {
+	if u, ok := s.users[username]; ok {
+		return u
+	}
+	return api.UserInfo{Username: username, OUsername: username, ID: username, Type: "uinf"}
+}
+
+func (s *Server) handleUserInfo(w http.ResponseWriter, username string) {
+	s.mu.Lock()
+	u, ok := s.users[username]
+	deleted := s.deleted[username]
+	if u.Flg == 0 {
+		u.Flg = len(s.followers[username])
+	}
+	if u.Flw == 0 {
+		u.Flw = len(s.following[username])
+	}
+	s.mu.Unlock()
+	if deleted {
+		writeError(w, "E_USER_DELETED", "user already deleted")
+		return
+	}
+	if !ok {
+		writeError(w, "E_USER_NOTFOUND", "user not found")
+		return
+	}
+	writeOK(w, map[string]interface{}{"data": u})
+}
+
+func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request, m map[string][]string, username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all := m[username]
+	start, end := page(r, len(all))
+	uinf := map[string]api.UserInfo{}
+	for _, u := range all[start:end] {
+		uinf[u] = s.userInfo(u)
+	}
+	writeOK(w, map[string]interface{}{
+		"aux": map[string]interface{}{"uinf": uinf},
+	})
+}
+
+func (s *Server) handlePosts(w http.ResponseWriter, r *http.Request, username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all := s.posts[username]
+	start, end := page(r, len(all))
+	posts := map[string]api.PostInfo{}
+	for _, p := range all[start:end] {
+		posts[p.ID] = p
+	}
+	writeOK(w, map[string]interface{}{
+		"aux": map[string]interface{}{"post": posts},
+	})
+}
+
+// findPost must be called with mu held.
+func (s *Server) findPost(id string) (api.PostInfo, string, bool) {
+	owner, ok := s.postOwners[id]
+	if !ok {
+		return api.PostInfo{}, "", false
+	}
+	for _, p := range s.posts[owner] {
+		if p.ID == id {
+			return p, owner, true
+		}
+	}
+	return api.PostInfo{}, "", false
+}
+
+func (s *Server) handlePost(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, owner, ok := s.findPost(id)
+	if !ok {
+		writeError(w, "E_POST_NOTFOUND", "post not found")
+		return
+	}
+	writeOK(w, map[string]interface{}{
+		"data": p,
+		"aux": map[string]interface{}{
+			"s_pst": api.ShareInfo{Comments: p.Cm, Likes: p.Lkbpst, Shares: p.Shbpst},
+			"uinf":  map[string]api.UserInfo{owner: s.userInfo(owner)},
+		},
+	})
+}
+
+func (s *Server) handleDeletePost(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, owner, ok := s.findPost(id)
+	if !ok {
+		writeOK(w, false)
+		return
+	}
+	var posts []api.PostInfo
+	for _, p := range s.posts[owner] {
+		if p.ID != id {
+			posts = append(posts, p)
+		}
+	}
+	s.posts[owner] = posts
+	delete(s.postOwners, id)
+	writeOK(w, true)
+}
+
+func (s *Server) handleComments(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all := s.comments[id]
+	start, end := page(r, len(all))
+	cmts := map[string]api.CommentInfo{}
+	uinf := map[string]api.UserInfo{}
+	for _, c := range all[start:end] {
+		cmts[c.ID] = c
+		uinf[c.UID] = s.userInfo(c.UID)
+	}
+	writeOK(w, map[string]interface{}{
+		"aux": map[string]interface{}{"cmt": cmts, "uinf": uinf},
+	})
+}
+
+func (s *Server) handleCreatePost(w http.ResponseWriter, r *http.Request) {
+	var content struct {
+		Data struct {
+			Text          string   `json:"txt"`
+			Description   string   `json:"dsc"`
+			UID           string   `json:"uid"`
+			Images        []string `json:"imgs"`
+			PreviewImage  string   `json:"previmg"`
+			PreviewSource string   `json:"prevsrc"`
+			Title         string   `json:"ttl"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(r.FormValue("content")), &content); err != nil {
+		writeError(w, "E_BAD_PARAMS", err.Error())
+		return
+	}
+	d := content.Data
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	now := api.IntDate(time.Now().UnixMilli())
+	p := api.PostInfo{
+		ID:      fmt.Sprintf("fake%d", s.nextID),
+		Type:    "post",
+		UID:     d.UID,
+		CDate:   now,
+		Update:  now,
+		Txt:     d.Text,
+		Dsc:     d.Description,
+		Ttl:     d.Title,
+		IMGs:    d.Images,
+		Previmg: d.PreviewImage,
+		Prevsrc: d.PreviewSource,
+	}
+	s.posts[d.UID] = append([]api.PostInfo{p}, s.posts[d.UID]...)
+	s.postOwners[p.ID] = d.UID
+	writeOK(w, map[string]interface{}{
+		"data": api.CreatePostInfo{
+			CDate: p.CDate,
+			UDate: p.Update,
+			UID:   d.UID,
+			Type:  p.Type,
+			ID:    p.ID,
+			Text:  p.Txt,
+		},
+	})
+}
+
+type searchContent struct {
+	Query  string `json:"q"`
+	Max    int    `json:"max"`
+	Offset int    `json:"offset"`
+}
+
+func readSearch(r *http.Request) (searchContent, error) {
+	var body struct {
+		Content searchContent `json:"content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return searchContent{}, err
+	}
+	return body.Content, nil
+}
+
+func searchPage(c searchContent, n int) (int, int) {
+	max := c.Max
+	if max <= 0 {
+		max = 20
+	}
+	start := c.Offset
+	if start > n {
+		start = n
+	}
+	end := start + max
+	if end > n {
+		end = n
+	}
+	return start, end
+}
+
+func (s *Server) handleSearchPosts(w http.ResponseWriter, r *http.Request) {
+	c, err := readSearch(r)
+	if err != nil {
+		writeError(w, "E_BAD_PARAMS", err.Error())
+		return
+	}
+	q := strings.ToLower(c.Query)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var owners []string
+	for u := range s.posts {
+		owners = append(owners, u)
+	}
+	sort.Strings(owners)
+	var matches []api.PostInfo
+	for _, u := range owners {
+		for _, p := range s.posts[u] {
+			if strings.Contains(strings.ToLower(p.Txt), q) {
+				matches = append(matches, p)
+			}
+		}
+	}
+	start, end := searchPage(c, len(matches))
+	posts := map[string]api.PostInfo{}
+	for _, p := range matches[start:end] {
+		posts[p.ID] = p
+	}
+	writeOK(w, map[string]interface{}{
+		"aux": map[string]interface{}{"post": posts},
+	})
+}
+
+func (s *Server) handleSearchUsers(w http.ResponseWriter, r *http.Request) {
+	c, err := readSearch(r)
+	if err != nil {
+		writeError(w, "E_BAD_PARAMS", err.Error())
+		return
+	}
+	q := strings.ToLower(c.Query)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matches []string
+	for username, u := range s.users {
+		if strings.Contains(strings.ToLower(username), q) || strings.Contains(strings.ToLower(u.Nickname), q) {
+			matches = append(matches, username)
+		}
+	}
+	sort.Strings(matches)
+	start, end := searchPage(c, len(matches))
+	uinf := map[string]api.UserInfo{}
+	for _, u := range matches[start:end] {
+		uinf[u] = s.users[u]
+	}
+	writeOK(w, map[string]interface{}{
+		"aux": map[string]interface{}{"uinf": uinf},
+	})
+}
+
+func (s *Server) handleStartUpload(w http.ResponseWriter) {
+	s.mu.Lock()
+	s.nextID++
+	id := fmt.Sprintf("upload%d", s.nextID)
+	s.mu.Unlock()
+	w.Header().Set("Location", "/media/big/upload/"+id)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) handleFinishUpload(w http.ResponseWriter, r *http.Request, id string) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ori := "group/fake/" + id + "/" + r.Header.Get("filename")
+	s.mu.Lock()
+	s.uploads[ori] = body
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.UploadInfo{
+		ORI:    "/" + ori,
+		Status: http.StatusOK,
+	})
+}
@@ -0,0 +1,150 @@
+package api_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/api/fakegettr"
+)
+
+const (
+	fakeUsername = "fakeuser"
+	fakeOther    = "fakeother"
+)
+
+func makeFakeFollowers(n int) []string {
+	var res []string
+	for i := 0; i < n; i++ {
+		res = append(res, fmt.Sprintf("follower%03d", i))
+	}
+	return res
+}
+
+func TestFakeGetFollowers(t *testing.T) {
+	followers := makeFakeFollowers(50)
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Followers: map[string][]string{fakeOther: followers},
+	})
+	defer s.Close()
+	c := s.MakeClient(fakeUsername)
+
+	infos, err := c.GetFollowers(fakeOther, api.FollowersOffset(20), api.FollowersMax(20))
+	if err != nil {
+		t.Fatalf("GetFollowers: %v", err)
+	}
+	var got []string
+	for _, u := range infos {
+		got = append(got, u.Username)
+	}
+	sort.Strings(got)
+	if want := followers[20:40]; !reflect.DeepEqual(want, got) {
+		t.Errorf("GetFollowers: want != got: %v %v", want, got)
+	}
+}
+
+func TestFakeAllFollowersParallel(t *testing.T) {
+	followers := makeFakeFollowers(53)
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Followers: map[string][]string{fakeOther: followers},
+	})
+	defer s.Close()
+	c := api.MakeExtended(s.MakeClient(fakeUsername))
+
+	userInfos, userNames, errs := c.AllFollowersParallel(fakeOther, api.AllFollowersMax(7), api.AllFollowersThreads(4))
+
+	var got, gotByOffset []string
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for u := range userInfos {
+			got = append(got, u.Username)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for so := range userNames {
+			gotByOffset = append(gotByOffset, so.Strings...)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for err := range errs {
+			t.Errorf("AllFollowersParallel: %v", err)
+		}
+	}()
+	wg.Wait()
+
+	sort.Strings(got)
+	sort.Strings(gotByOffset)
+	if want := followers; !reflect.DeepEqual(want, got) {
+		t.Errorf("AllFollowersParallel: want != got: %v %v", want, got)
+	}
+	if want := followers; !reflect.DeepEqual(want, gotByOffset) {
+		t.Errorf("AllFollowersParallel by offset: want != got: %v %v", want, gotByOffset)
+	}
+}
+
+func TestFakeCreatePost(t *testing.T) {
+	s := fakegettr.Make(nil)
+	defer s.Close()
+	c := s.MakeClient(fakeUsername)
+
+	info, err := c.CreatePost("hello world", api.CreatePostTitle("title"))
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	if info.ID == "" {
+		t.Fatalf("CreatePost: empty ID: %+v", info)
+	}
+	if got, want := info.Text, "hello world"; got != want {
+		t.Errorf("CreatePost: got %q but expected %q", got, want)
+	}
+
+	posts, err := c.GetPosts(fakeUsername)
+	if err != nil {
+		t.Fatalf("GetPosts: %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("GetPosts: expected 1 post but got %d: %+v", len(posts), posts)
+	}
+	if got, want := posts[0].ID, info.ID; got != want {
+		t.Errorf("GetPosts: got %q but expected %q", got, want)
+	}
+	if got, want := posts[0].Title(), "title"; got != want {
+		t.Errorf("GetPosts: got %q but expected %q", got, want)
+	}
+}
+
+func TestFakeUpload(t *testing.T) {
+	s := fakegettr.Make(nil)
+	defer s.Close()
+	c := s.MakeClient(fakeUsername)
+
+	f := path.Join(t.TempDir(), "image.png")
+	want := []byte("not really a png")
+	if err := ioutil.WriteFile(f, want, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	info, err := c.Upload(f)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if info.ORI == "" {
+		t.Fatalf("Upload: empty ORI: %+v", info)
+	}
+	got, ok := s.Upload(info.ORI)
+	if !ok {
+		t.Fatalf("Upload: nothing uploaded to %s", info.ORI)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Upload: want != got: %q %q", want, got)
+	}
+}
@@ -1,12 +1,26 @@
 // DO NOT EDIT MANUALLY: Generated from https://github.com/spudtrooper/genopts
 package api
 
-//go:generate genopts --prefix=MakeClient --outfile=makeclientoptions.go "debug:bool"
+import (
+	"net/http"
+	"time"
+)
+
+//go:generate genopts --prefix=MakeClient --outfile=makeclientoptions.go "debug:bool" "transport:http.RoundTripper" "baseURL:string" "recordRequests:string" "replayRequests:string" "rateLimit:float64" "uploadRateLimit:float64" "maxRetries:int" "minBackoff:time.Duration" "maxBackoff:time.Duration"
 
 type MakeClientOption func(*makeClientOptionImpl)
 
 type MakeClientOptions interface {
 	Debug() bool
+	Transport() http.RoundTripper
+	BaseURL() string
+	RecordRequests() string
+	ReplayRequests() string
+	RateLimit() float64
+	UploadRateLimit() float64
+	MaxRetries() int
+	MinBackoff() time.Duration
+	MaxBackoff() time.Duration
 }
 
 func MakeClientDebug(debug bool) MakeClientOption {
@@ -20,11 +34,128 @@ func MakeClientDebugFlag(debug *bool) MakeClientOption {
 	}
 }
 
+func MakeClientTransport(transport http.RoundTripper) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.transport = transport
+	}
+}
+func MakeClientTransportFlag(transport *http.RoundTripper) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.transport = *transport
+	}
+}
+
+func MakeClientBaseURL(baseURL string) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.baseURL = baseURL
+	}
+}
+func MakeClientBaseURLFlag(baseURL *string) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.baseURL = *baseURL
+	}
+}
+
+func MakeClientRecordRequests(recordRequests string) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.recordRequests = recordRequests
+	}
+}
+func MakeClientRecordRequestsFlag(recordRequests *string) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.recordRequests = *recordRequests
+	}
+}
+
+func MakeClientReplayRequests(replayRequests string) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.replayRequests = replayRequests
+	}
+}
+func MakeClientReplayRequestsFlag(replayRequests *string) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.replayRequests = *replayRequests
+	}
+}
+
+func MakeClientRateLimit(rateLimit float64) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.rateLimit = rateLimit
+	}
+}
+func MakeClientRateLimitFlag(rateLimit *float64) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.rateLimit = *rateLimit
+	}
+}
+
+func MakeClientUploadRateLimit(uploadRateLimit float64) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.uploadRateLimit = uploadRateLimit
+	}
+}
+func MakeClientUploadRateLimitFlag(uploadRateLimit *float64) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.uploadRateLimit = *uploadRateLimit
+	}
+}
+
+func MakeClientMaxRetries(maxRetries int) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.maxRetries = maxRetries
+	}
+}
+func MakeClientMaxRetriesFlag(maxRetries *int) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.maxRetries = *maxRetries
+	}
+}
+
+func MakeClientMinBackoff(minBackoff time.Duration) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.minBackoff = minBackoff
+	}
+}
+func MakeClientMinBackoffFlag(minBackoff *time.Duration) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.minBackoff = *minBackoff
+	}
+}
+
+func MakeClientMaxBackoff(maxBackoff time.Duration) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.maxBackoff = maxBackoff
+	}
+}
+func MakeClientMaxBackoffFlag(maxBackoff *time.Duration) MakeClientOption {
+	return func(opts *makeClientOptionImpl) {
+		opts.maxBackoff = *maxBackoff
+	}
+}
+
 type makeClientOptionImpl struct {
-	debug bool
+	debug           bool
+	transport       http.RoundTripper
+	baseURL         string
+	recordRequests  string
+	replayRequests  string
+	rateLimit       float64
+	uploadRateLimit float64
+	maxRetries      int
+	minBackoff      time.Duration
+	maxBackoff      time.Duration
 }
 
-func (m *makeClientOptionImpl) Debug() bool { return m.debug }
+func (m *makeClientOptionImpl) Debug() bool                  { return m.debug }
+func (m *makeClientOptionImpl) Transport() http.RoundTripper { return m.transport }
+func (m *makeClientOptionImpl) BaseURL() string              { return m.baseURL }
+func (m *makeClientOptionImpl) RecordRequests() string       { return m.recordRequests }
+func (m *makeClientOptionImpl) ReplayRequests() string       { return m.replayRequests }
+func (m *makeClientOptionImpl) RateLimit() float64           { return m.rateLimit }
+func (m *makeClientOptionImpl) UploadRateLimit() float64     { return m.uploadRateLimit }
+func (m *makeClientOptionImpl) MaxRetries() int              { return m.maxRetries }
+func (m *makeClientOptionImpl) MinBackoff() time.Duration    { return m.minBackoff }
+func (m *makeClientOptionImpl) MaxBackoff() time.Duration    { return m.maxBackoff }
 
 func makeMakeClientOptionImpl(opts ...MakeClientOption) *makeClientOptionImpl {
 	res := &makeClientOptionImpl{}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"math"
+	"sync"
+)
+
+type paginateOptions struct {
+	start, max, threads int
+	// total is the expected number of items, or zero if it isn't known.
+	total int
+	// ordered yields pages in offset order rather than as they arrive.
+	ordered bool
+}
+
+// pager hands out offsets to workers and learns where the stream ends.
+type pager struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	max      int
+	total    int
+	next     int
+	end      int
+	inflight int
+}
+
+func makePager(opts paginateOptions) *pager {
+	p := &pager{
+		max:   opts.max,
+		total: opts.total,
+		next:  opts.start,
+		end:   math.MaxInt,
+	}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// take returns the next offset to fetch, or false once there are no more or ctx is done. Offsets below the
+// expected total are handed out freely, but past it we only probe one page at a time, so a stale total can't
+// cause workers to fan out far beyond the end.
+func (p *pager) take(ctx context.Context) (int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		if ctx.Err() != nil || p.next >= p.end || p.next > math.MaxInt-p.max {
+			return 0, false
+		}
+		if p.total > 0 && p.next >= p.total && p.inflight > 0 {
+			p.cond.Wait()
+			continue
+		}
+		offset := p.next
+		p.next += p.max
+		p.inflight++
+		return offset, true
+	}
+}
+
+// done records that the page at offset was fetched; an empty page marks the end of the stream.
+func (p *pager) done(offset int, empty bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inflight--
+	if empty && offset < p.end {
+		p.end = offset
+	}
+	p.cond.Broadcast()
+}
+
+func (p *pager) wake() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cond.Broadcast()
+}
+
+type pageResult[T any] struct {
+	Page[T]
+	err error
+}
+
+// paginate fetches pages of max items from opts.start with opts.threads workers until a page comes back
+// empty, and sends the non-empty pages to the first channel. A page that fails is reported on the second
+// channel, which is buffered so that it needn't be read concurrently, and the worker that fetched it stops.
+// Both channels are closed when done or once ctx is done.
+func paginate[T any](ctx context.Context, opts paginateOptions, fetch func(ctx context.Context, offset, max int) ([]T, error)) (chan Page[T], chan error) {
+	ctx, cancel := context.WithCancel(ctx)
+	p := makePager(opts)
+
+	// Waiters in take need a nudge when ctx is done, since they block on the cond and not on ctx.
+	go func() {
+		<-ctx.Done()
+		p.wake()
+	}()
+
+	results := make(chan pageResult[T])
+	go func() {
+		var wg sync.WaitGroup
+		for i := 0; i < opts.threads; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					offset, ok := p.take(ctx)
+					if !ok {
+						return
+					}
+					items, err := fetch(ctx, offset, opts.max)
+					p.done(offset, err == nil && len(items) == 0)
+					select {
+					case results <- pageResult[T]{Page: Page[T]{Offset: offset, Items: items}, err: err}:
+					case <-ctx.Done():
+						return
+					}
+					// A worker stops after an error, which bounds the number of errors by the number of threads.
+					if err != nil {
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(results)
+	}()
+
+	pages := make(chan Page[T])
+	errs := make(chan error, opts.threads)
+	go func() {
+		defer cancel()
+		defer close(errs)
+		defer close(pages)
+
+		send := func(r pageResult[T]) bool {
+			if r.err != nil {
+				errs <- r.err
+				return true
+			}
+			if len(r.Items) == 0 {
+				return true
+			}
+			select {
+			case pages <- r.Page:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if !opts.ordered {
+			for r := range results {
+				if !send(r) {
+					return
+				}
+			}
+			return
+		}
+
+		// Every offset handed out produces exactly one result, so holding results until their predecessors
+		// arrive yields pages in order without gaps.
+		pending := map[int]pageResult[T]{}
+		next := opts.start
+		for r := range results {
+			pending[r.Offset] = r
+			for {
+				r, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next += opts.max
+				if !send(r) {
+					return
+				}
+			}
+		}
+	}()
+
+	return pages, errs
+}
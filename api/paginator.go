@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"sync"
+
+	"github.com/spudtrooper/goutil/or"
+)
+
+// Page is a page of results and the offset it was read from.
+type Page[T any] struct {
+	Offset int
+	Items  []T
+}
+
+// Cursor is the position of a Paginator. Save it and pass it to PaginatorCursor to resume where a paginator
+// left off.
+type Cursor struct {
+	Offset int
+	Done   bool
+}
+
+// PageFunc fetches up to max items starting at offset.
+type PageFunc[T any] func(ctx context.Context, offset, max int) ([]T, error)
+
+// Paginator reads every item of an offset-paginated route, either a page at a time with Next, all at once with
+// All or Each, or with concurrent workers with Parallel. The stream ends at the first empty page.
+type Paginator[T any] struct {
+	fetch   PageFunc[T]
+	max     int
+	threads int
+	total   int
+	ordered bool
+
+	mu        sync.Mutex
+	cursor    Cursor
+	delivered map[int]bool
+}
+
+func MakePaginator[T any](fetch PageFunc[T], pOpts ...PaginatorOption) *Paginator[T] {
+	opts := MakePaginatorOptions(pOpts...)
+	return &Paginator[T]{
+		fetch:   fetch,
+		max:     or.Int(opts.Max(), defaultMax),
+		threads: or.Int(opts.Threads(), defaultThreads),
+		total:   opts.Total(),
+		ordered: opts.Ordered(),
+		cursor:  opts.Cursor(),
+	}
+}
+
+// Cursor returns the position up to which every page has been read.
+func (p *Paginator[T]) Cursor() Cursor {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cursor
+}
+
+// Next reads the next page, returning false once the stream is exhausted. After an error the cursor doesn't
+// move, so calling Next again retries the same page.
+func (p *Paginator[T]) Next(ctx context.Context) (Page[T], bool, error) {
+	cursor := p.Cursor()
+	if cursor.Done {
+		return Page[T]{}, false, nil
+	}
+	items, err := p.fetch(ctx, cursor.Offset, p.max)
+	if err != nil {
+		return Page[T]{}, false, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(items) == 0 {
+		p.cursor.Done = true
+		return Page[T]{}, false, nil
+	}
+	p.cursor.Offset += p.max
+	return Page[T]{Offset: cursor.Offset, Items: items}, true, nil
+}
+
+// Each calls f with each remaining page in order, stopping at the first error.
+func (p *Paginator[T]) Each(ctx context.Context, f func(page Page[T]) error) error {
+	for {
+		page, ok, err := p.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := f(page); err != nil {
+			return err
+		}
+	}
+}
+
+// All returns every remaining item in order.
+func (p *Paginator[T]) All(ctx context.Context) ([]T, error) {
+	var res []T
+	if err := p.Each(ctx, func(page Page[T]) error {
+		res = append(res, page.Items...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Parallel reads the remaining pages with concurrent workers. Pages come in offset order only if the
+// paginator was made with PaginatorOrdered. Failed pages are reported on the error channel, which is buffered,
+// and leave a gap that the cursor won't move past, so resuming from it reads them again. Once ctx is done the
+// workers stop and both channels are closed, so callers that stop reading early should cancel ctx.
+func (p *Paginator[T]) Parallel(ctx context.Context) (chan Page[T], chan error) {
+	cursor := p.Cursor()
+	pages := make(chan Page[T])
+	errs := make(chan error, p.threads)
+	if cursor.Done {
+		close(pages)
+		close(errs)
+		return pages, errs
+	}
+
+	inPages, inErrs := paginate(ctx, paginateOptions{
+		start:   cursor.Offset,
+		max:     p.max,
+		threads: p.threads,
+		total:   p.total,
+		ordered: p.ordered,
+	}, p.fetch)
+
+	var wg sync.WaitGroup
+	var failed bool
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for page := range inPages {
+			select {
+			case pages <- page:
+				p.markDelivered(page.Offset)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for err := range inErrs {
+			failed = true
+			errs <- err
+		}
+	}()
+	go func() {
+		wg.Wait()
+		if !failed && ctx.Err() == nil {
+			p.mu.Lock()
+			p.cursor.Done = true
+			p.mu.Unlock()
+		}
+		close(pages)
+		close(errs)
+	}()
+
+	return pages, errs
+}
+
+// markDelivered records that the page at offset was read and moves the cursor past every page read so far
+// without a gap.
+func (p *Paginator[T]) markDelivered(offset int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.delivered == nil {
+		p.delivered = map[int]bool{}
+	}
+	p.delivered[offset] = true
+	for p.delivered[p.cursor.Offset] {
+		delete(p.delivered, p.cursor.Offset)
+		p.cursor.Offset += p.max
+	}
+}
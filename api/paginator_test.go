@@ -0,0 +1,146 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/api/fakegettr"
+)
+
+func makeFakeComments(post string, n int) []api.CommentInfo {
+	var res []api.CommentInfo
+	for i := 0; i < n; i++ {
+		res = append(res, api.CommentInfo{ID: fmt.Sprintf("c%03d", i), PID: post, UID: fakeUsername})
+	}
+	return res
+}
+
+func commentIDs(cs []api.CommentInfo) []string {
+	var res []string
+	for _, c := range cs {
+		res = append(res, c.ID)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func TestPaginatorNextAndResume(t *testing.T) {
+	comments := makeFakeComments("p1", 25)
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Comments: map[string][]api.CommentInfo{"p1": comments},
+	})
+	defer s.Close()
+	c := api.MakeExtended(s.MakeClient(fakeUsername))
+	ctx := context.Background()
+
+	p := c.AllComments("p1", api.AllCommentsMax(10))
+	page, ok, err := p.Next(ctx)
+	if err != nil || !ok {
+		t.Fatalf("Next: ok=%t err=%v", ok, err)
+	}
+	if got, want := p.Cursor(), (api.Cursor{Offset: 10}); got != want {
+		t.Errorf("Cursor: got %+v but expected %+v", got, want)
+	}
+
+	// Resume a new paginator from the first one's cursor.
+	rest, err := api.MakePaginator(func(ctx context.Context, offset, max int) ([]api.CommentInfo, error) {
+		return c.GetCommentsContext(ctx, "p1", api.CommentsOffset(offset), api.CommentsMax(max))
+	}, api.PaginatorCursor(p.Cursor()), api.PaginatorMax(10)).All(ctx)
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if want, got := commentIDs(comments), commentIDs(append(page.Items, rest...)); !reflect.DeepEqual(want, got) {
+		t.Errorf("want != got: %v %v", want, got)
+	}
+
+	all, err := c.AllComments("p1", api.AllCommentsMax(10)).All(ctx)
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if want, got := commentIDs(comments), commentIDs(all); !reflect.DeepEqual(want, got) {
+		t.Errorf("All: want != got: %v %v", want, got)
+	}
+}
+
+func TestPaginatorParallelCursor(t *testing.T) {
+	comments := makeFakeComments("p1", 50)
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Comments: map[string][]api.CommentInfo{"p1": comments},
+	})
+	defer s.Close()
+	c := api.MakeExtended(s.MakeClient(fakeUsername, api.MakeClientMaxRetries(-1)))
+	ctx := context.Background()
+
+	// One of the first pages fails, so the cursor has to stay before it even though later pages are read.
+	s.FailNext(1, http.StatusBadRequest)
+	p := c.AllComments("p1", api.AllCommentsMax(10), api.AllCommentsThreads(2))
+	pages, errs := p.Parallel(ctx)
+	var read int
+	for range pages {
+		read++
+	}
+	var failures int
+	for range errs {
+		failures++
+	}
+	if failures != 1 {
+		t.Errorf("expected 1 failure but got %d", failures)
+	}
+	if want := 4; read != want {
+		t.Errorf("expected %d pages but got %d", want, read)
+	}
+	if got := p.Cursor(); got.Done || got.Offset > 10 {
+		t.Errorf("Cursor after a failure: got %+v but expected it before the failed page", got)
+	}
+
+	p = c.AllComments("p1", api.AllCommentsMax(10), api.AllCommentsThreads(3), api.AllCommentsOrdered(true))
+	pages, errs = p.Parallel(ctx)
+	var got []api.CommentInfo
+	timeout := time.After(5 * time.Second)
+	for pages != nil {
+		select {
+		case page, ok := <-pages:
+			if !ok {
+				pages = nil
+				break
+			}
+			got = append(got, page.Items...)
+		case <-timeout:
+			t.Fatalf("timed out reading pages")
+		}
+	}
+	for err := range errs {
+		t.Errorf("Parallel: %v", err)
+	}
+	if want, got := commentIDs(comments), commentIDs(got); !reflect.DeepEqual(want, got) {
+		t.Errorf("Parallel: want != got: %v %v", want, got)
+	}
+	if got, want := p.Cursor(), (api.Cursor{Offset: 50, Done: true}); got != want {
+		t.Errorf("Cursor when done: got %+v but expected %+v", got, want)
+	}
+}
+
+func TestAllSearchUsers(t *testing.T) {
+	var users []api.UserInfo
+	for i := 0; i < 12; i++ {
+		users = append(users, api.UserInfo{Username: fmt.Sprintf("match%02d", i)})
+	}
+	users = append(users, api.UserInfo{Username: "other"})
+	s := fakegettr.Make(&fakegettr.Fixtures{Users: users})
+	defer s.Close()
+	c := api.MakeExtended(s.MakeClient(fakeUsername))
+
+	got, err := c.AllSearchUsers("match", api.AllSearchMax(5)).All(context.Background())
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if want := 12; len(got) != want {
+		t.Errorf("expected %d users but got %d", want, len(got))
+	}
+}
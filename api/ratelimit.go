@@ -0,0 +1,227 @@
+package api
+
+import (
+	"context"
+	"flag"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/goutil/flags"
+)
+
+const (
+	apiHost    = "api.gettr.com"
+	uploadHost = "upload.gettr.com"
+
+	defaultRateLimit       = 50
+	defaultUploadRateLimit = 5
+	defaultMaxRetries      = 5
+	defaultMinBackoff      = 500 * time.Millisecond
+	defaultMaxBackoff      = 30 * time.Second
+
+	// A throttled host is never slowed below this fraction of its configured rate.
+	minRateFraction = 1.0 / 32
+	// Each successful request recovers this fraction of a throttled host's configured rate.
+	recoverRateFraction = 1.0 / 20
+)
+
+var (
+	clientRateLimit       = flag.Float64("client_rate_limit", defaultRateLimit, "max requests per second to api.gettr.com; negative for no limit")
+	clientUploadRateLimit = flag.Float64("client_upload_rate_limit", defaultUploadRateLimit, "max requests per second to upload.gettr.com; negative for no limit")
+	clientMaxRetries      = flags.Int("client_max_retries", "max times to retry a throttled or failed request; zero for the default and negative for none")
+)
+
+// rateLimiter keeps a token bucket per host that's shared by everything using a Core.
+type rateLimiter struct {
+	rateLimit, uploadRateLimit float64
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+func makeRateLimiter(rateLimit, uploadRateLimit float64) *rateLimiter {
+	if rateLimit == 0 {
+		rateLimit = defaultRateLimit
+	}
+	if uploadRateLimit == 0 {
+		uploadRateLimit = defaultUploadRateLimit
+	}
+	return &rateLimiter{
+		rateLimit:       rateLimit,
+		uploadRateLimit: uploadRateLimit,
+		hosts:           map[string]*hostLimiter{},
+	}
+}
+
+// forHost returns the limiter for host, or nil if requests to host aren't limited.
+func (r *rateLimiter) forHost(host string) *hostLimiter {
+	rate := r.rateLimit
+	if host == uploadHost {
+		rate = r.uploadRateLimit
+	}
+	if rate < 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.hosts[host]
+	if !ok {
+		h = &hostLimiter{maxRate: rate, rate: rate, tokens: math.Max(1, rate), last: time.Now()}
+		r.hosts[host] = h
+	}
+	return h
+}
+
+// hostLimiter is a token bucket whose rate halves each time the host pushes back and climbs back up as
+// requests succeed. A nil *hostLimiter doesn't limit anything.
+type hostLimiter struct {
+	mu          sync.Mutex
+	maxRate     float64
+	rate        float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// wait blocks until a request may be sent or ctx is done.
+func (h *hostLimiter) wait(ctx context.Context) error {
+	for {
+		d := h.reserve()
+		if d <= 0 {
+			return ctx.Err()
+		}
+		if err := sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve takes a token and returns zero if one is available, otherwise how long to wait before trying again.
+func (h *hostLimiter) reserve() time.Duration {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	if now.Before(h.pausedUntil) {
+		return h.pausedUntil.Sub(now)
+	}
+	h.tokens = math.Min(math.Max(1, h.rate), h.tokens+now.Sub(h.last).Seconds()*h.rate)
+	h.last = now
+	if h.tokens >= 1 {
+		h.tokens--
+		return 0
+	}
+	return time.Duration((1 - h.tokens) / h.rate * float64(time.Second))
+}
+
+// throttled halves the rate and, if the host asked us to wait for retryAfter, holds all requests until then.
+func (h *hostLimiter) throttled(retryAfter time.Duration) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rate = math.Max(h.rate/2, h.maxRate*minRateFraction)
+	h.tokens = math.Min(h.tokens, 0)
+	if until := time.Now().Add(retryAfter); until.After(h.pausedUntil) {
+		h.pausedUntil = until
+	}
+}
+
+func (h *hostLimiter) succeeded() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rate = math.Min(h.maxRate, h.rate+h.maxRate*recoverRateFraction)
+}
+
+type retryPolicy struct {
+	maxRetries             int
+	minBackoff, maxBackoff time.Duration
+}
+
+func makeRetryPolicy(maxRetries int, minBackoff, maxBackoff time.Duration) retryPolicy {
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+	if minBackoff == 0 {
+		minBackoff = defaultMinBackoff
+	}
+	if maxBackoff == 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
+	}
+	return retryPolicy{maxRetries: maxRetries, minBackoff: minBackoff, maxBackoff: maxBackoff}
+}
+
+// shouldRetry returns whether a request that failed with err and res after attempt retries should be sent
+// again. Block pages are served before the request reaches GETTR, so those are always retried, but server
+// errors are only retried for idempotent methods so that e.g. a post isn't created twice.
+func (p retryPolicy) shouldRetry(attempt int, method string, res *http.Response, err error) bool {
+	if attempt >= p.maxRetries {
+		return false
+	}
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	if res == nil || res.StatusCode < 500 {
+		return false
+	}
+	switch method {
+	case "GET", "HEAD", "PUT", "DELETE", "OPTIONS":
+		return true
+	}
+	return false
+}
+
+// backoff returns how long to wait before the retry after attempt: exponential with jitter in [d/2, d].
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	d := p.maxBackoff
+	if attempt < 32 {
+		if b := p.minBackoff << uint(attempt); b > 0 && b < d {
+			d = b
+		}
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryAfter returns the delay asked for in res's Retry-After header, either in seconds or as an HTTP date.
+func retryAfter(res *http.Response) time.Duration {
+	if res == nil {
+		return 0
+	}
+	v := res.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// sleep waits for d or until ctx is done, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
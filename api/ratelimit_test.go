@@ -0,0 +1,65 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/api/fakegettr"
+)
+
+func TestRetries(t *testing.T) {
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Users: []api.UserInfo{{Username: fakeOther, ID: fakeOther}},
+	})
+	defer s.Close()
+	c := s.MakeClient(fakeUsername, api.MakeClientRateLimit(-1),
+		api.MakeClientMinBackoff(time.Millisecond), api.MakeClientMaxBackoff(5*time.Millisecond))
+
+	s.RateLimitNext(2)
+	if _, err := c.GetUserInfo(fakeOther); err != nil {
+		t.Fatalf("GetUserInfo: expected to succeed after retries but got %v", err)
+	}
+	if got, want := len(s.Requests()), 3; got != want {
+		t.Errorf("expected %d requests but got %d", want, got)
+	}
+
+	s.FailNext(1, http.StatusServiceUnavailable)
+	if _, err := c.GetUserInfo(fakeOther); err != nil {
+		t.Fatalf("GetUserInfo: expected to succeed after a 503 but got %v", err)
+	}
+
+	// Server errors aren't retried for posts, since the first one may have gone through.
+	s.FailNext(1, http.StatusServiceUnavailable)
+	_, err := c.CreatePost("hello")
+	var statusErr *api.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("CreatePost: expected a 503 StatusError but got %v", err)
+	}
+
+	s.RateLimitNext(10)
+	if _, err := c.GetUserInfo(fakeOther); !errors.Is(err, api.ErrRateLimited) {
+		t.Errorf("GetUserInfo: expected ErrRateLimited once retries are exhausted but got %v", err)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Users: []api.UserInfo{{Username: fakeOther, ID: fakeOther}},
+	})
+	defer s.Close()
+	c := s.MakeClient(fakeUsername, api.MakeClientRateLimit(20))
+
+	// The first 20 requests use up the burst and the next 10 have to wait for 1/20s each.
+	start := time.Now()
+	for i := 0; i < 30; i++ {
+		if _, err := c.GetUserInfo(fakeOther); err != nil {
+			t.Fatalf("GetUserInfo: %v", err)
+		}
+	}
+	if elapsed, min := time.Since(start), 400*time.Millisecond; elapsed < min {
+		t.Errorf("expected 30 requests at 20/s to take at least %v but took %v", min, elapsed)
+	}
+}
@@ -2,16 +2,22 @@ package cli
 
 import (
 	"context"
+	"encoding/csv"
 	"flag"
 	"fmt"
 	"math/rand"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/spudtrooper/gettr/api"
 	"github.com/spudtrooper/gettr/log"
 	"github.com/spudtrooper/gettr/model"
+	"github.com/spudtrooper/gettr/model/graph"
+	postquery "github.com/spudtrooper/gettr/model/query"
 	"github.com/spudtrooper/goutil/flags"
 	"github.com/spudtrooper/goutil/formatstruct"
 	goutilio "github.com/spudtrooper/goutil/io"
@@ -44,14 +50,47 @@ var (
 	debug                  = flags.Bool("debug", "generic debug for some actions")
 	query                  = flags.String("query", "query for search")
 	banner                 = flags.Bool("banner", "print banner before commands")
+	snapshotKind           = flags.String("snapshot_kind", "followers or following; empty means both for TakeSnapshot and followers otherwise")
+	fromSnapshot           = flags.String("from_snapshot", "ID of the older snapshot to diff; defaults to the one before --to_snapshot")
+	toSnapshot             = flags.String("to_snapshot", "ID of the newer snapshot to diff; defaults to the latest")
+	snapshotSince          = flags.String("snapshot_since", "diff against the latest snapshot taken on or before this date, e.g. 2022-03-07")
+	historyWindow          = flag.Duration("history_window", 0, "how far back to look at user info history, e.g. 720h; zero for all of it")
+	csvFile                = flags.String("csv_file", "file to which to write CSV instead of stdout")
+	trackFor               = flag.Duration("track_for", 48*time.Hour, "how long TrackPost tracks a post's engagement; zero for forever")
+	trackInterval          = flag.Duration("track_interval", 10*time.Minute, "how often TrackEngagement samples tracked posts")
+	trackOnce              = flags.Bool("track_once", "sample tracked posts once instead of every --track_interval")
+	velocityWindow         = flag.Duration("velocity_window", 24*time.Hour, "PostEngagement reports the velocity over this long after a post was created")
+	queryAuthors           = flags.String("query_authors", "comma-separated users who wrote the posts QueryPosts finds")
+	querySince             = flags.String("query_since", "QueryPosts finds posts created on or after this date, e.g. 2022-03-07")
+	queryUntil             = flags.String("query_until", "QueryPosts finds posts created before this date, e.g. 2022-03-14")
+	queryHashtags          = flags.String("query_hashtags", "comma-separated hashtags that posts found by QueryPosts all have")
+	queryLang              = flags.String("query_lang", "language of posts found by QueryPosts, e.g. en")
+	queryMinLikes          = flags.Int("query_min_likes", "min likes of posts found by QueryPosts")
+	queryMinShares         = flags.Int("query_min_shares", "min shares of posts found by QueryPosts")
+	queryMinComments       = flags.Int("query_min_comments", "min comments of posts found by QueryPosts")
+	queryHasImages         = flags.Bool("query_has_images", "QueryPosts only finds posts with images")
+	querySort              = flags.String("query_sort", "order of posts found by QueryPosts: newest (the default), likes, shares or comments")
+	queryLimit             = flag.Int("query_limit", 100, "max posts QueryPosts finds; zero for all of them")
+	queryFormat            = flags.String("query_format", "how QueryPosts writes posts: table (the default), json or csv")
+	queryOutput            = flags.String("query_output", "file to which QueryPosts writes instead of stdout")
+	topN                   = flag.Int("top_n", 20, "how many hashtags or mentions TopHashtags and TopMentions print")
+	topWindow              = flag.Duration("top_window", 0, "TopHashtags and TopMentions count posts and comments from this far back, e.g. 168h; zero for all of them")
+	topPostsOnly           = flags.Bool("top_posts_only", "TopHashtags and TopMentions only count posts, not comments")
+	graphWindow            = flag.Duration("graph_window", 0, "InteractionGraph uses posts and comments from this far back, e.g. 720h; zero for all of them")
+	graphFormat            = flags.String("graph_format", "format in which graphs are written: csv, json, graphml, gexf or dot; defaults to the extension of --graph_output, or csv")
+	graphOutput            = flags.String("graph_output", "file to which graphs are written instead of stdout")
+	exportDepth            = flag.Int("export_depth", 1, "how many hops from --other ExportGraph walks followers and following")
+	exportMaxNodes         = flags.Int("export_max_nodes", "max users ExportGraph includes; zero for no max")
+	exportFollowersOnly    = flags.Bool("export_followers_only", "ExportGraph only walks followers")
+	exportFollowingOnly    = flags.Bool("export_following_only", "ExportGraph only walks following")
 )
 
 func isLimitExceeded(err error) bool {
-	return strings.Contains(err.Error(), "E_METER_LIMIT_EXCEEDED")
+	return errors.Is(err, api.ErrLimitExceeded)
 }
 
 func isLimited(err error) bool {
-	return strings.Contains(err.Error(), "LIMITED")
+	return errors.Is(err, api.ErrRateLimited)
 }
 
 func Main(ctx context.Context) error {
@@ -157,6 +196,13 @@ func Main(ctx context.Context) error {
 		return res
 	}
 
+	requireDB := func() (*model.DB, error) {
+		if db := f.DB(); db != nil {
+			return db, nil
+		}
+		return nil, errors.Errorf("this command needs --store=mongo")
+	}
+
 	mustFormatString := func(x interface{}) string {
 		return fmt.Sprintf("<<<\n%s\n>>>", formatstruct.MustFormatString(x))
 	}
@@ -335,6 +381,80 @@ func Main(ctx context.Context) error {
 		return nil
 	})
 
+	app.Register("CommentThread", func(ctx context.Context) error {
+		requireStringFlag(postID, "post_id")
+		thread, err := client.CommentThreadContext(ctx, *postID, api.CommentThreadMax(*max), api.CommentThreadThreads(*threads))
+		if err != nil {
+			return err
+		}
+		var print func(nodes []*api.CommentNode, indent string)
+		print = func(nodes []*api.CommentNode, indent string) {
+			for _, n := range nodes {
+				fmt.Printf("%s%s: %s\n", indent, n.Author.Username, n.Comment.Text)
+				print(n.Replies, indent+"  ")
+			}
+		}
+		print(thread.Comments, "")
+		log.Printf("%d comments on %s", thread.Count(), *postID)
+		return nil
+	})
+
+	app.Register("BackfillComments", func(ctx context.Context) error {
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		postIDs, err := db.GetPostIDs(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("backfilling comments for %d posts", len(postIDs))
+		ids := make(chan interface{})
+		go func() {
+			defer close(ids)
+			for _, id := range postIDs {
+				ids <- id
+			}
+		}()
+		threads := or.Int(*threads, 20)
+		parallel.ExecAndDrain(ids, threads, func(x interface{}) (interface{}, error) {
+			id := x.(string)
+			if !*force {
+				if n, err := db.CountComments(ctx, id); err == nil && n > 0 {
+					return nil, nil
+				}
+			}
+			thread, err := client.CommentThreadContext(ctx, id, api.CommentThreadMax(*max))
+			if err != nil {
+				log.Printf("CommentThread(%s): %v", id, err)
+				if isLimitExceeded(err) {
+					log.Fatalf("Limit exceeded: %v", err)
+				}
+				return nil, err
+			}
+			var comments []api.CommentInfo
+			var flatten func(nodes []*api.CommentNode)
+			flatten = func(nodes []*api.CommentNode) {
+				for _, n := range nodes {
+					comments = append(comments, n.Comment)
+					flatten(n.Replies)
+				}
+			}
+			flatten(thread.Comments)
+			if err := db.AddComments(ctx, id, comments); err != nil {
+				return nil, err
+			}
+			log.Printf("stored %d comments for %s", len(comments), id)
+			return nil, nil
+		})
+		count, err := db.CountComments(ctx, "")
+		if err != nil {
+			return err
+		}
+		log.Printf("%d comments stored", count)
+		return nil
+	})
+
 	app.Register("GetPost", func(context.Context) error {
 		requireStringFlag(postID, "post_id")
 		info, err := client.GetPost(*postID)
@@ -713,6 +833,581 @@ func Main(ctx context.Context) error {
 		return nil
 	})
 
+	app.Register("DedupePosts", func(ctx context.Context) error {
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		deleted, err := db.DedupePosts(ctx)
+		if err != nil {
+			return err
+		}
+		count, err := db.CountPosts(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("deleted %d duplicate post(s), %d remain", deleted, count)
+		return nil
+	})
+
+	snapshotKinds := func() ([]model.SnapshotKind, error) {
+		switch kind := model.SnapshotKind(*snapshotKind); kind {
+		case "":
+			return []model.SnapshotKind{model.SnapshotFollowers, model.SnapshotFollowing}, nil
+		case model.SnapshotFollowers, model.SnapshotFollowing:
+			return []model.SnapshotKind{kind}, nil
+		}
+		return nil, errors.Errorf("invalid --snapshot_kind %q: must be followers or following", *snapshotKind)
+	}
+
+	// readFollowish re-reads the followers or following of u into the store, so that a snapshot is of who they
+	// are now rather than whenever they were last read.
+	readFollowish := func(u *model.User, kind model.SnapshotKind) (int, error) {
+		var users chan *model.User
+		var errs chan error
+		if kind == model.SnapshotFollowers {
+			users, errs = u.Followers(ctx, model.UserFollowersThreads(*threads), model.UserFollowersForce(true))
+		} else {
+			users, errs = u.Following(ctx, model.UserFollowingThreads(*threads), model.UserFollowingForce(true))
+		}
+		var firstErr error
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for err := range errs {
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}()
+		n := 0
+		for range users {
+			n++
+		}
+		wg.Wait()
+		return n, firstErr
+	}
+
+	app.Register("TakeSnapshot", func(ctx context.Context) error {
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		kinds, err := snapshotKinds()
+		if err != nil {
+			return err
+		}
+		u := defaultUser()
+		for _, kind := range kinds {
+			if _, err := readFollowish(u, kind); err != nil {
+				return err
+			}
+			snapshot, err := db.TakeSnapshot(ctx, u.Username(), kind)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s %s %s: %d\n", snapshot.ID, u.Username(), kind, snapshot.Count)
+		}
+		return nil
+	})
+
+	app.Register("ListSnapshots", func(ctx context.Context) error {
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		kinds, err := snapshotKinds()
+		if err != nil {
+			return err
+		}
+		username := defaultUsername()
+		for _, kind := range kinds {
+			snapshots, err := db.GetSnapshots(ctx, username, kind)
+			if err != nil {
+				return err
+			}
+			for _, s := range snapshots {
+				incomplete := ""
+				if !s.Complete {
+					incomplete = " (incomplete)"
+				}
+				fmt.Printf("%s %s %s %s: %d%s\n", s.ID, s.Created.Local().Format(time.RFC3339), username, kind, s.Count, incomplete)
+			}
+		}
+		return nil
+	})
+
+	app.Register("DiffSnapshots", func(ctx context.Context) error {
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		fromID, toID := *fromSnapshot, *toSnapshot
+		if fromID == "" || toID == "" {
+			kind := model.SnapshotKind(or.String(*snapshotKind, string(model.SnapshotFollowers)))
+			snapshots, err := db.GetSnapshots(ctx, defaultUsername(), kind)
+			if err != nil {
+				return err
+			}
+			to := len(snapshots) - 1
+			if toID != "" {
+				for to >= 0 && snapshots[to].ID != toID {
+					to--
+				}
+			}
+			from := to - 1
+			if *snapshotSince != "" {
+				since, err := time.ParseInLocation("2006-01-02", *snapshotSince, time.Local)
+				if err != nil {
+					return errors.Errorf("invalid --snapshot_since: %v", err)
+				}
+				// Anything taken on that day counts.
+				since = since.AddDate(0, 0, 1)
+				for from >= 0 && !snapshots[from].Created.Before(since) {
+					from--
+				}
+			}
+			if to < 0 || (fromID == "" && from < 0) {
+				return errors.Errorf("not enough %s snapshots to diff; take them with TakeSnapshot", kind)
+			}
+			toID = snapshots[to].ID
+			if fromID == "" {
+				fromID = snapshots[from].ID
+			}
+		}
+		diff, err := db.DiffSnapshots(ctx, fromID, toID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s's %s from %s (%d) to %s (%d): gained %d, lost %d\n",
+			diff.From.Username, diff.From.Kind,
+			diff.From.Created.Local().Format(time.RFC3339), diff.From.Count,
+			diff.To.Created.Local().Format(time.RFC3339), diff.To.Count,
+			len(diff.Gained), len(diff.Lost))
+		for _, u := range diff.Gained {
+			fmt.Printf("+ %s\n", u)
+		}
+		for _, u := range diff.Lost {
+			fmt.Printf("- %s\n", u)
+		}
+		return nil
+	})
+
+	app.Register("SampleUserInfo", func(ctx context.Context) error {
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		var usernames []string
+		if *usernamesFile != "" {
+			c, err := goutilio.StringsFromFile(*usernamesFile, goutilio.StringsFromFileSkipEmpty(true))
+			if err != nil {
+				return err
+			}
+			for u := range c {
+				usernames = append(usernames, u)
+			}
+		} else {
+			usernames = append(usernames, defaultUsername())
+		}
+		for _, username := range usernames {
+			userInfo, err := client.GetUserInfoContext(ctx, username)
+			if err != nil {
+				return err
+			}
+			fetched := time.Now()
+			if err := db.SetUserInfo(ctx, username, userInfo); err != nil {
+				return err
+			}
+			if err := db.AddUserInfoSample(ctx, username, userInfo, fetched); err != nil {
+				return err
+			}
+			log.Printf("%s: %d followers, %d following", username, userInfo.Followers(), userInfo.Following())
+		}
+		return nil
+	})
+
+	app.Register("UserInfoHistory", func(ctx context.Context) error {
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		username := defaultUsername()
+		var since time.Time
+		if *historyWindow > 0 {
+			since = time.Now().Add(-*historyWindow)
+		}
+		samples, err := db.GetUserInfoHistory(ctx, username, since, time.Time{})
+		if err != nil {
+			return err
+		}
+
+		out := os.Stdout
+		if *csvFile != "" {
+			f, err := os.Create(*csvFile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		w := csv.NewWriter(out)
+		if err := w.Write([]string{"time", "followers", "following", "twitter_followers", "twitter_following", "influence"}); err != nil {
+			return err
+		}
+		for _, s := range samples {
+			if err := w.Write([]string{
+				s.Time.Format(time.RFC3339),
+				strconv.Itoa(s.Followers),
+				strconv.Itoa(s.Following),
+				strconv.Itoa(s.TwitterFollowers),
+				strconv.Itoa(s.TwitterFollowing),
+				strconv.Itoa(s.Influence),
+			}); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+
+		growth, err := db.GetFollowerGrowth(ctx, username, *historyWindow)
+		if err != nil {
+			return err
+		}
+		if growth == nil {
+			log.Printf("no user info history for %s; record some with SampleUserInfo", username)
+			return nil
+		}
+		log.Printf("%s went from %d to %d followers (%+d, %.1f/day) since %s",
+			username, growth.From.Followers, growth.To.Followers, growth.Followers, growth.FollowersPerDay,
+			growth.From.Time.Local().Format(time.RFC3339))
+		return nil
+	})
+
+	app.Register("TrackPost", func(ctx context.Context) error {
+		requireStringFlag(postID, "post_id")
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		var until time.Time
+		if *trackFor > 0 {
+			until = time.Now().Add(*trackFor)
+		}
+		if err := db.TrackPost(ctx, *postID, until); err != nil {
+			return err
+		}
+		// Sample it right away so that there's something from early on.
+		sample, err := model.SampleEngagement(ctx, client.Core, db, *postID)
+		if err != nil {
+			return err
+		}
+		log.Printf("tracking %s: %d comments, %d likes, %d shares", *postID, sample.Comments, sample.Likes, sample.Shares)
+		return nil
+	})
+
+	app.Register("UntrackPost", func(ctx context.Context) error {
+		requireStringFlag(postID, "post_id")
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		return db.UntrackPost(ctx, *postID)
+	})
+
+	app.Register("TrackEngagement", func(ctx context.Context) error {
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		return model.TrackEngagement(ctx, client.Core, db,
+			model.TrackEngagementInterval(*trackInterval),
+			model.TrackEngagementThreads(*threads),
+			model.TrackEngagementOnce(*trackOnce))
+	})
+
+	app.Register("PostEngagement", func(ctx context.Context) error {
+		requireStringFlag(postID, "post_id")
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		samples, err := db.GetEngagementHistory(ctx, *postID)
+		if err != nil {
+			return err
+		}
+
+		out := os.Stdout
+		if *csvFile != "" {
+			f, err := os.Create(*csvFile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		w := csv.NewWriter(out)
+		if err := w.Write([]string{"time", "comments", "likes", "shares"}); err != nil {
+			return err
+		}
+		for _, s := range samples {
+			if err := w.Write([]string{
+				s.Time.Format(time.RFC3339),
+				strconv.Itoa(s.Comments),
+				strconv.Itoa(s.Likes),
+				strconv.Itoa(s.Shares),
+			}); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+
+		printVelocity := func(what string, since, until time.Time) error {
+			v, err := db.GetEngagementVelocity(ctx, *postID, since, until)
+			if err != nil {
+				return err
+			}
+			if v == nil {
+				log.Printf("%s: no samples", what)
+				return nil
+			}
+			log.Printf("%s: %.1f likes/hour, %.1f comments/hour, %.1f shares/hour over %.1f hours",
+				what, v.LikesPerHour, v.CommentsPerHour, v.SharesPerHour, v.Hours)
+			return nil
+		}
+		tracked, err := db.GetTrackedPost(ctx, *postID)
+		if err != nil {
+			return err
+		}
+		if tracked != nil && !tracked.Created.IsZero() {
+			if err := printVelocity(fmt.Sprintf("first %v", *velocityWindow), tracked.Created, tracked.Created.Add(*velocityWindow)); err != nil {
+				return err
+			}
+		}
+		return printVelocity("overall", time.Time{}, time.Now())
+	})
+
+	topTermsOptions := func() []model.TopTermsOption {
+		res := []model.TopTermsOption{
+			model.TopTermsUsername(*other),
+			model.TopTermsLimit(*topN),
+			model.TopTermsPostsOnly(*topPostsOnly),
+		}
+		if *topWindow > 0 {
+			res = append(res, model.TopTermsSince(time.Now().Add(-*topWindow)))
+		}
+		return res
+	}
+
+	app.Register("TopHashtags", func(ctx context.Context) error {
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		top, err := db.TopHashtags(ctx, topTermsOptions()...)
+		if err != nil {
+			return err
+		}
+		suggestions, err := client.GetSuggestionsContext(ctx, api.SuggestMax(*topN))
+		if err != nil {
+			return err
+		}
+		suggested := map[string]bool{}
+		for _, s := range suggestions {
+			suggested[strings.ToLower(strings.TrimPrefix(s.Topic, "#"))] = true
+		}
+		found := map[string]bool{}
+		for i, t := range top {
+			found[t.Term] = true
+			var note string
+			if suggested[t.Term] {
+				note = " (suggested)"
+			}
+			fmt.Printf("%3d. #%s %d%s\n", i+1, t.Term, t.Count, note)
+		}
+		for _, s := range suggestions {
+			if t := strings.ToLower(strings.TrimPrefix(s.Topic, "#")); !found[t] {
+				log.Printf("suggested but not in the top %d: #%s", *topN, t)
+			}
+		}
+		return nil
+	})
+
+	app.Register("TopMentions", func(ctx context.Context) error {
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		top, err := db.TopMentions(ctx, topTermsOptions()...)
+		if err != nil {
+			return err
+		}
+		for i, t := range top {
+			fmt.Printf("%3d. @%s %d\n", i+1, t.Term, t.Count)
+		}
+		return nil
+	})
+
+	app.Register("BackfillEntities", func(ctx context.Context) error {
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		n, err := db.BackfillEntities(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("extracted the hashtags, mentions and URLs of %d post(s) and comment(s)", n)
+		return nil
+	})
+
+	graphFormatFromFlags := func() (graph.Format, error) {
+		if *graphFormat == "" {
+			return graph.FormatForFile(*graphOutput)
+		}
+		return graph.ParseFormat(*graphFormat)
+	}
+
+	writeGraph := func(g *graph.Graph, format graph.Format) error {
+		out := os.Stdout
+		if *graphOutput != "" {
+			f, err := os.Create(*graphOutput)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		if err := g.Write(out, format); err != nil {
+			return err
+		}
+		log.Printf("wrote %d users and %d edges", len(g.Nodes()), len(g.Edges()))
+		return nil
+	}
+
+	app.Register("InteractionGraph", func(ctx context.Context) error {
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		format, err := graphFormatFromFlags()
+		if err != nil {
+			return err
+		}
+		var bOpts []graph.BuildOption
+		if *other != "" {
+			bOpts = append(bOpts, graph.BuildUsernames([]string{*other}))
+		}
+		if *graphWindow > 0 {
+			bOpts = append(bOpts, graph.BuildSince(time.Now().Add(-*graphWindow)))
+		}
+		g, err := graph.BuildInteractions(ctx, db, bOpts...)
+		if err != nil {
+			return err
+		}
+		return writeGraph(g, format)
+	})
+
+	app.Register("ExportGraph", func(ctx context.Context) error {
+		if *exportFollowersOnly && *exportFollowingOnly {
+			return errors.Errorf("set at most one of --export_followers_only and --export_following_only")
+		}
+		format, err := graphFormatFromFlags()
+		if err != nil {
+			return err
+		}
+		g, err := graph.BuildFollows(ctx, f, defaultUsername(),
+			graph.FollowsDepth(*exportDepth),
+			graph.FollowsMaxNodes(*exportMaxNodes),
+			graph.FollowsFollowersOnly(*exportFollowersOnly),
+			graph.FollowsFollowingOnly(*exportFollowingOnly),
+			graph.FollowsThreads(*threads))
+		if err != nil {
+			return err
+		}
+		return writeGraph(g, format)
+	})
+
+	app.Register("QueryPosts", func(ctx context.Context) error {
+		db, err := requireDB()
+		if err != nil {
+			return err
+		}
+		sort, err := postquery.ParseSort(*querySort)
+		if err != nil {
+			return err
+		}
+		format, err := postquery.ParseFormat(*queryFormat)
+		if err != nil {
+			return err
+		}
+		splitList := func(s string) []string {
+			var res []string
+			for _, x := range strings.Split(s, ",") {
+				if x = strings.TrimSpace(x); x != "" {
+					res = append(res, x)
+				}
+			}
+			return res
+		}
+		parseDate := func(s, name string) (time.Time, error) {
+			if s == "" {
+				return time.Time{}, nil
+			}
+			t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+			if err != nil {
+				return time.Time{}, errors.Errorf("invalid --%s: %v", name, err)
+			}
+			return t, nil
+		}
+		since, err := parseDate(*querySince, "query_since")
+		if err != nil {
+			return err
+		}
+		until, err := parseDate(*queryUntil, "query_until")
+		if err != nil {
+			return err
+		}
+		posts, err := postquery.Find(ctx, db, postquery.Posts{
+			Text:        *query,
+			Authors:     splitList(*queryAuthors),
+			Since:       since,
+			Until:       until,
+			Hashtags:    splitList(*queryHashtags),
+			Lang:        *queryLang,
+			MinLikes:    *queryMinLikes,
+			MinShares:   *queryMinShares,
+			MinComments: *queryMinComments,
+			HasImages:   *queryHasImages,
+			Sort:        sort,
+			Limit:       *queryLimit,
+		})
+		if err != nil {
+			return err
+		}
+
+		out := os.Stdout
+		if *queryOutput != "" {
+			f, err := os.Create(*queryOutput)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		if err := postquery.Write(out, format, posts); err != nil {
+			return err
+		}
+		log.Printf("found %d post(s)", len(posts))
+		return nil
+	})
+
 	app.Register("Upload", func(context.Context) error {
 		requireStringFlag(uploadImage, "upload_image")
 		var img string
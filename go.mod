@@ -8,6 +8,7 @@ replace github.com/spudtrooper/minimalcli => ../minimalcli
 
 require (
 	github.com/fatih/color v1.13.0
+	github.com/mattn/go-sqlite3 v1.14.15
 	github.com/pkg/errors v0.9.1
 	github.com/spudtrooper/goutil v0.1.106
 	github.com/spudtrooper/minimalcli v0.0.0-20220218140555-74daced67dea
@@ -0,0 +1,84 @@
+// Crawls the followers and following of users out to several hops, storing them as it goes. Stopping it and
+// running it again with the same users resumes where it left off.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/log"
+	"github.com/spudtrooper/gettr/model"
+	"github.com/spudtrooper/goutil/check"
+	"github.com/spudtrooper/goutil/flags"
+	goutilio "github.com/spudtrooper/goutil/io"
+)
+
+var (
+	usernames     = flags.String("usernames", "comma-separated users to crawl from")
+	usernamesFile = flags.String("usernames_file", "file of users to crawl from, one per line")
+	name          = flags.String("crawl_name", "name under which the crawl is checkpointed; defaults to the users crawled from")
+	depth         = flag.Int("crawl_depth", 2, "how many hops from the users to crawl")
+	maxPerLevel   = flags.Int("crawl_max_per_level", "max users to crawl at each hop, keeping those followed by or following the most users crawled in the hop before")
+	maxFollowers  = flags.Int("crawl_max_followers", "skip users with more followers than this")
+	followersOnly = flags.Bool("crawl_followers_only", "only crawl followers")
+	followingOnly = flags.Bool("crawl_following_only", "only crawl following")
+	threads       = flags.Int("threads", "threads with which to read followers and following")
+	restart       = flags.Bool("restart", "start the crawl over instead of resuming it")
+)
+
+func crawl(ctx context.Context) error {
+	var seeds []string
+	if *usernames != "" {
+		seeds = append(seeds, strings.Split(*usernames, ",")...)
+	}
+	if *usernamesFile != "" {
+		lines, err := goutilio.StringsFromFile(*usernamesFile, goutilio.StringsFromFileSkipEmpty(true))
+		if err != nil {
+			return err
+		}
+		for l := range lines {
+			seeds = append(seeds, l)
+		}
+	}
+	if len(seeds) == 0 {
+		return errors.Errorf("set --usernames or --usernames_file")
+	}
+
+	f, err := model.MakeFactoryFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+	stats, err := model.Crawl(ctx, f, seeds,
+		model.CrawlName(*name),
+		model.CrawlDepth(*depth),
+		model.CrawlMaxPerLevel(*maxPerLevel),
+		model.CrawlMaxFollowers(*maxFollowers),
+		model.CrawlFollowersOnly(*followersOnly),
+		model.CrawlFollowingOnly(*followingOnly),
+		model.CrawlThreads(*threads),
+		model.CrawlRestart(*restart),
+		model.CrawlOnUser(func(u model.CrawledUser) {
+			if u.Skipped != "" {
+				log.Printf("skipped %s at depth %d: %s", u.Username, u.Depth, u.Skipped)
+				return
+			}
+			log.Printf("crawled %s at depth %d: %d followers, %d following", u.Username, u.Depth, u.Followers, u.Following)
+		}))
+	log.Printf("crawled %d user(s), skipped %d and found %d", stats.Expanded, stats.Skipped, stats.Found)
+	if errors.Is(err, context.Canceled) {
+		log.Printf("stopped; run again to resume")
+		return nil
+	}
+	return err
+}
+
+func main() {
+	flag.Parse()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	check.Err(crawl(ctx))
+}
@@ -6,6 +6,8 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -14,16 +16,15 @@ import (
 	"github.com/fatih/color"
 	"github.com/spudtrooper/gettr/api"
 	"github.com/spudtrooper/gettr/model"
+	"github.com/spudtrooper/gettr/model/queue"
 	"github.com/spudtrooper/gettr/todo"
 	"github.com/spudtrooper/goutil/check"
 	"github.com/spudtrooper/goutil/flags"
 	"github.com/spudtrooper/goutil/or"
 	"github.com/spudtrooper/goutil/parallel"
 	"github.com/spudtrooper/goutil/ranges"
-	"github.com/spudtrooper/goutil/sets"
 	"github.com/spudtrooper/goutil/timing"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -37,60 +38,39 @@ var (
 	forceFollowers      = flags.Bool("force_followers", "force to pull fresh followers")
 	restart             = flags.Bool("restart", "when true we create the queue of followers")
 	showMonitoringTitle = flags.Bool("show_monitoring_title", "show the monitoring title every so often")
+	incremental         = flags.Bool("incremental", "only read posts newer than each user's newest stored post, instead of resuming from the last offset read; for refreshing users whose posts were all read before")
+	leaseTimeout        = flag.Duration("lease_timeout", 5*time.Minute, "how long a user is leased before it's handed to another worker if the lease isn't extended")
+	maxAttempts         = flags.Int("max_attempts", "times to try a user before dead-lettering them; zero for the default")
+	retryDead           = flags.Bool("retry_dead", "put users that failed too many times back in the queue")
 )
 
 const (
-	allCollection        = "crawlFollowersQueue"
-	doneCollection       = "crawlFollowersDone"
 	maxOffsetsCollection = "crawlmaxOffsets"
 )
 
-func connect(ctx context.Context) (*mongo.Database, error) {
-	const port = 27017
-	const dbName = "gettrwork"
-
-	uri := fmt.Sprintf("mongodb://localhost:%d", port)
-	clientOptions := options.Client().ApplyURI(uri)
-
-	client, err := mongo.Connect(ctx, clientOptions)
-	if err != nil {
-		return nil, err
-	}
-	if err := client.Ping(ctx, nil); err != nil {
-		return nil, err
-	}
-
-	return client.Database(dbName), nil
-}
-
-// For each user we maining two lists:
-//   - allCollection: all the users to process; this never is mutated
-//     after being filled.
-//   - doneCollection: all the users that have been processed; we remove
-//     a user after processing them.
+// The users to process, --other and their followers, are kept in a queue named for --other. Each worker leases
+// a user and:
+//   - Finds all their posts and adds them to the gettr DB `posts` table.
+//   - Marks them done, or failed so that they're retried later.
 //
-// To restart set the --restart flag.
-//
-// For each user followed by --other, we:
-//   - Find all posts and add these posts to the gettr DB `posts` table.
-//   - Remove user from doneCollection
+// To refill the queue set the --restart flag.
 func crawl(ctx context.Context) {
 	f, err := model.MakeFactoryFromFlags(ctx)
 	check.Err(err)
 	username := *other
 	other := f.MakeUser(username)
 
-	db, err := connect(ctx)
+	workDB, err := model.MakeDBFromFlags(ctx, model.MakeDBDbName("gettrwork"), model.MakeDBSchemaless(true))
+	check.Err(err)
+	db := workDB.Database()
+	q, err := queue.Make(ctx, db, "getposts/"+username,
+		queue.MakeVisibilityTimeout(*leaseTimeout),
+		queue.MakeMaxAttempts(*maxAttempts))
 	check.Err(err)
 
 	timing.SetLog(log.Printf)
 	timing.GetOptions().Color = true
 
-	type storedQueue struct {
-		Username string
-		Users    []string
-	}
-
 	type storedMaxOffset struct {
 		Username string
 		Offset   int
@@ -158,22 +138,11 @@ func crawl(ctx context.Context) {
 				users = append(users, u.Username())
 			}
 		})
-		storeUsers := func(collection string, users []string) {
-			filter := bson.D{{"username", username}}
-			db.Collection(collection).DeleteMany(ctx, filter)
-			stored := storedQueue{
-				Username: username,
-				Users:    users,
-			}
-			if _, err := db.Collection(collection).InsertOne(ctx, stored); err != nil {
-				check.Err(err)
-			}
-		}
-		timing.Time("storeUsers(allCollection)", func() {
-			storeUsers(allCollection, users)
-		})
-		timing.Time("storeUsers(doneCollection)", func() {
-			storeUsers(doneCollection, []string{})
+		timing.Time("fill queue", func() {
+			check.Err(q.Reset(ctx))
+			added, err := q.Add(ctx, users...)
+			check.Err(err)
+			log.Printf("queued %d users", added)
 		})
 		timing.Time("updateMaxOffsets", func() {
 			usersCh := make(chan string)
@@ -205,64 +174,18 @@ func crawl(ctx context.Context) {
 		timing.Push("process")
 		defer timing.Pop()
 
-		usersToProcessCh := make(chan string)
-		var numAll, numDone, numRemaining int
+		var numRemaining int
 		{
-			filter := bson.D{{"username", username}}
-
-			var storedDone storedQueue
-			var done sets.StringSet
-			timing.Time("find(doneCollection)", func() {
-				check.Err(db.Collection(doneCollection).FindOne(ctx, filter).Decode(&storedDone))
-				done = sets.String(storedDone.Users)
-			})
-
-			var storedAll storedQueue
-			var all []string
-			timing.Time("find(allCollection)", func() {
-				check.Err(db.Collection(allCollection).FindOne(ctx, filter).Decode(&storedAll))
-				all = storedAll.Users
-			})
-
-			var remaining []string
-			for _, u := range all {
-				if !done[u] {
-					remaining = append(remaining, u)
-				}
-			}
-			numAll = len(all)
-			numDone = len(done)
-			numRemaining = len(remaining)
-			cyan := func(i int) string {
+			stats, err := q.Stats(ctx)
+			check.Err(err)
+			numRemaining = int(stats.Remaining())
+			cyan := func(i int64) string {
 				return color.New(color.FgCyan).Sprintf("%d", i)
 			}
-			log.Printf("found %s users to process", cyan(numAll))
-			log.Printf("found %s users completed", cyan(numDone))
-			log.Printf("found %s users remaining", cyan(numRemaining))
-			go func() {
-				for _, u := range remaining {
-					usersToProcessCh <- u
-				}
-				close(usersToProcessCh)
-			}()
-		}
-
-		var doneMu sync.Mutex
-		markDone := func(user string) {
-			doneMu.Lock()
-			defer doneMu.Unlock()
-			filter := bson.D{{"username", username}}
-			var stored storedQueue
-			check.Err(db.Collection(doneCollection).FindOne(ctx, filter).Decode(&stored))
-			after := append(stored.Users, user)
-			// TODO: What's the right way to update an array in mongodb?
-			update := bson.D{
-				{"$set", bson.D{
-					{"users", after},
-				}},
-			}
-			_, err := db.Collection(doneCollection).UpdateOne(ctx, filter, update)
-			check.Err(err)
+			log.Printf("found %s users to process", cyan(stats.Total()))
+			log.Printf("found %s users completed", cyan(stats.Done))
+			log.Printf("found %s users remaining", cyan(stats.Remaining()))
+			log.Printf("found %s users dead-lettered", cyan(stats.Dead))
 		}
 
 		postsThreads := or.Int(*postsThreads, 2)
@@ -271,15 +194,27 @@ func crawl(ctx context.Context) {
 		log.Printf("processing followers with %d threads", processThreads)
 
 		var userCount, grandTotal, usersWithPosts int32
-		processUser := func(user string) {
-			start := findMaxOffset(user)
+		var postsInserted, postsUpdated int64
+		processUser := func(user string) error {
 			max := or.Int(*postsMax, 20)
-			posts, errors := f.Client().AllPosts(user,
-				api.AllPostsThreads(postsThreads),
-				api.AllPostsMax(max),
-				api.AllPostsStart(start))
+			postsOpts := []api.AllPostsOption{api.AllPostsThreads(postsThreads), api.AllPostsMax(max)}
+			if *incremental {
+				// Offsets shift as users post, so new posts are found from the newest stored one.
+				since, err := f.Store().GetLatestPostCDate(ctx, user)
+				if err != nil {
+					return err
+				}
+				postsOpts = append(postsOpts, api.AllPostsSince(since))
+			} else {
+				postsOpts = append(postsOpts, api.AllPostsStart(findMaxOffset(user)))
+			}
+			// Cancelled when we stop reading posts, so the workers don't block forever.
+			userCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			posts, errors := f.Client().AllPostsContext(userCtx, user, postsOpts...)
 			atomic.AddInt32(&userCount, 1)
 			var total int
+			var storeErr, readErr error
 			parallel.WaitFor(func() {
 				for ps := range posts {
 					ps := ps
@@ -288,19 +223,86 @@ func crawl(ctx context.Context) {
 					if len(ps.Posts) == 0 {
 						break
 					}
-					if err := f.DB().AddPostInfos(ctx, user, ps.Posts); err != nil {
-						todo.SkipErr("AddPosts", err)
+					counts, err := f.Store().AddPostInfos(ctx, user, ps.Posts)
+					if err != nil {
+						storeErr = err
+						cancel()
+						break
+					}
+					atomic.AddInt64(&postsInserted, counts.Inserted)
+					atomic.AddInt64(&postsUpdated, counts.Updated)
+					if !*incremental {
+						updateMaxOffset(user, ps.Offset)
 					}
-					updateMaxOffset(user, ps.Offset)
 				}
 			}, func() {
+				var errs []error
 				for e := range errors {
 					log.Printf("error: %v", e)
+					errs = append(errs, e)
+				}
+				if len(errs) > 0 && userCtx.Err() == nil {
+					// The offsets read are kept, so a retry picks up from the failed page.
+					readErr = errs[0]
 				}
 			})
 			if total > 0 {
 				atomic.AddInt32(&usersWithPosts, 1)
 			}
+			if storeErr != nil {
+				return storeErr
+			}
+			return readErr
+		}
+
+		// leaseAndProcess processes users until none are left, extending each lease while the user is processed.
+		leaseAndProcess := func() {
+			for ctx.Err() == nil {
+				item, err := q.Lease(ctx)
+				if err != nil {
+					todo.SkipErr("Lease", err)
+					return
+				}
+				if item == nil {
+					stats, err := q.Stats(ctx)
+					if err != nil {
+						todo.SkipErr("Stats", err)
+						return
+					}
+					if stats.Remaining() == 0 {
+						return
+					}
+					// Waiting on retries or other workers' leases.
+					time.Sleep(time.Second)
+					continue
+				}
+
+				extendCtx, stopExtending := context.WithCancel(ctx)
+				go func() {
+					ticker := time.NewTicker(q.VisibilityTimeout() / 2)
+					defer ticker.Stop()
+					for {
+						select {
+						case <-extendCtx.Done():
+							return
+						case <-ticker.C:
+							if err := q.Extend(extendCtx, item); err != nil && extendCtx.Err() == nil {
+								log.Printf("extending lease of %s: %v", item.Key, err)
+							}
+						}
+					}
+				}()
+				err = processUser(item.Key)
+				stopExtending()
+				if err != nil {
+					log.Printf("processing %s (attempt %d): %v", item.Key, item.Attempts, err)
+					if err := q.Fail(ctx, item, err); err != nil {
+						todo.SkipErr("Fail", err)
+					}
+				} else if err := q.Complete(ctx, item); err != nil {
+					todo.SkipErr("Complete", err)
+				}
+			}
 		}
 
 		start := time.Now()
@@ -337,20 +339,24 @@ func crawl(ctx context.Context) {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				for u := range usersToProcessCh {
-					processUser(u)
-					go func(u string) {
-						markDone(u)
-					}(u)
-				}
+				leaseAndProcess()
 			}()
 		})
 		wg.Wait()
+		log.Printf("stored %d new post(s) and updated %d", postsInserted, postsUpdated)
+		if stats, err := q.Stats(ctx); err == nil && stats.Dead > 0 {
+			log.Printf("%d user(s) failed too many times; rerun with --retry_dead to retry them", stats.Dead)
+		}
 	}
 
 	if *restart {
 		restartQueues()
 	}
+	if *retryDead {
+		n, err := q.RetryDead(ctx)
+		check.Err(err)
+		log.Printf("retrying %d dead-lettered user(s)", n)
+	}
 
 	processing = true
 	process()
@@ -359,5 +365,7 @@ func crawl(ctx context.Context) {
 
 func main() {
 	flag.Parse()
-	crawl(context.Background())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	crawl(ctx)
 }
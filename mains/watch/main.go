@@ -0,0 +1,91 @@
+// Watches users until interrupted: stores their info, new posts and, optionally, follower changes, and writes an
+// event for each change as a line of JSON. Restarting resumes where the last run left off.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/log"
+	"github.com/spudtrooper/gettr/model"
+	"github.com/spudtrooper/goutil/check"
+	"github.com/spudtrooper/goutil/flags"
+	goutilio "github.com/spudtrooper/goutil/io"
+)
+
+var (
+	usernames     = flags.String("usernames", "comma-separated users to watch, each optionally with its own interval, e.g. foo,bar:15m")
+	usernamesFile = flags.String("usernames_file", "file of users to watch, one per line in the same form as --usernames")
+	interval      = flag.Duration("watch_interval", time.Hour, "how often to check users without their own interval")
+	followers     = flags.Bool("watch_followers", "also snapshot followers each time and report who was gained and lost")
+	threads       = flags.Int("threads", "threads with which to read followers")
+	maxPostPages  = flags.Int("max_post_pages", "max pages of new posts to read each time; any left are read the next times before moving on")
+	eventsFile    = flags.String("watch_events", "file to which events are appended as JSON lines instead of stdout")
+)
+
+func watch(ctx context.Context) error {
+	var specs []string
+	if *usernames != "" {
+		specs = append(specs, strings.Split(*usernames, ",")...)
+	}
+	if *usernamesFile != "" {
+		lines, err := goutilio.StringsFromFile(*usernamesFile, goutilio.StringsFromFileSkipEmpty(true))
+		if err != nil {
+			return err
+		}
+		for l := range lines {
+			specs = append(specs, l)
+		}
+	}
+	targets, err := model.ParseWatchTargets(specs, *interval)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return errors.Errorf("set --usernames or --usernames_file")
+	}
+
+	var out io.Writer = os.Stdout
+	if *eventsFile != "" {
+		f, err := os.OpenFile(*eventsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	enc := json.NewEncoder(out)
+
+	f, err := model.MakeFactoryFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("watching %d user(s)", len(targets))
+	err = model.Watch(ctx, f, targets,
+		model.WatchFollowers(*followers),
+		model.WatchThreads(*threads),
+		model.WatchMaxPostPages(*maxPostPages),
+		model.WatchOnEvent(func(e model.WatchEvent) {
+			if err := enc.Encode(e); err != nil {
+				log.Printf("writing event: %v", err)
+			}
+		}))
+	if err == context.Canceled {
+		return nil
+	}
+	return err
+}
+
+func main() {
+	flag.Parse()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	check.Err(watch(ctx))
+}
@@ -30,6 +30,7 @@ type Cache interface {
 	GetAllStrings(parts ...string) (ShardedStrings, error)
 	FindKeys(parts ...string) ([]string, error)
 	FindKeysChannels(parts ...string) (chan string, chan error, error)
+	Delete(parts ...string) error
 }
 
 func MakeCacheFromFlags() (Cache, error) {
@@ -42,7 +43,7 @@ func MakeCacheFromFlags() (Cache, error) {
 
 func makeCache(dir string) *cacheImpl {
 	return &cacheImpl{
-		dir: *cacheDir,
+		dir: dir,
 	}
 }
 
@@ -132,6 +133,15 @@ func (c *cacheImpl) get(parts ...string) ([]byte, error) {
 	return b, nil
 }
 
+// Delete removes the value or directory at parts, if there is one.
+func (c *cacheImpl) Delete(parts ...string) error {
+	f := c.file(parts...)
+	if *cacheVerbose {
+		log.Printf("deleting %s", f)
+	}
+	return os.RemoveAll(f)
+}
+
 func (c *cacheImpl) GetBytes(parts ...string) ([]byte, error) {
 	return c.get(parts...)
 }
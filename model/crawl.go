@@ -0,0 +1,340 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/log"
+	"github.com/spudtrooper/goutil/or"
+)
+
+// CrawledUser is a user Crawl got to at Depth hops from the seeds, with how many followers and following were
+// read, or why they were skipped.
+type CrawledUser struct {
+	Username  string
+	Depth     int
+	Followers int
+	Following int
+	Skipped   string
+}
+
+type CrawlStats struct {
+	Expanded int
+	Skipped  int
+	// Found is how many users have been reached, including the seeds.
+	Found int
+}
+
+// A crawl is checkpointed in the cache under crawls/<name> as records that are each written once, apart from
+// the small state, so that saving after every user costs only what that user added:
+//   - state: the depth being crawled, how many users of its level are done and the stats so far.
+//   - levels/<depth>: the users to expand at depth, and seen/<depth> everyone found up to them.
+//   - found/<depth>/<username>: the users found from a user expanded at depth.
+//
+// The users found for the next level are rebuilt from the found records of the users done when resuming.
+
+type crawlState struct {
+	Depth int
+	Done  int
+	Stats CrawlStats
+}
+
+type crawlCheckpoint struct {
+	cache Cache
+	name  string
+}
+
+func (c crawlCheckpoint) load(v interface{}, parts ...string) (bool, error) {
+	parts = append([]string{"crawls", c.name}, parts...)
+	if has, err := c.cache.Has(parts...); err != nil || !has {
+		return false, err
+	}
+	b, err := c.cache.GetBytes(parts...)
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return false, errors.Wrapf(err, "invalid checkpoint of crawl %s at %s", c.name, strings.Join(parts, "/"))
+	}
+	return true, nil
+}
+
+func (c crawlCheckpoint) save(v interface{}, parts ...string) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.cache.SetBytes(b, append([]string{"crawls", c.name}, parts...)...)
+}
+
+func (c crawlCheckpoint) loadState() (*crawlState, error) {
+	var res crawlState
+	if ok, err := c.load(&res, "state"); err != nil || !ok {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c crawlCheckpoint) saveState(state crawlState) error {
+	return c.save(state, "state")
+}
+
+// loadLevel returns the users to expand at depth and everyone found up to them.
+func (c crawlCheckpoint) loadLevel(depth int) ([]string, map[string]bool, error) {
+	d := strconv.Itoa(depth)
+	var level, seen []string
+	if ok, err := c.load(&level, "levels", d); err != nil {
+		return nil, nil, err
+	} else if !ok {
+		return nil, nil, errors.Errorf("checkpoint of crawl %s has no users for depth %d", c.name, depth)
+	}
+	if _, err := c.load(&seen, "seen", d); err != nil {
+		return nil, nil, err
+	}
+	seenSet := map[string]bool{}
+	for _, u := range seen {
+		seenSet[u] = true
+	}
+	return level, seenSet, nil
+}
+
+// saveLevel saves the users to expand at depth and everyone found up to them.
+func (c crawlCheckpoint) saveLevel(depth int, level []string, seen map[string]bool) error {
+	d := strconv.Itoa(depth)
+	var seenList []string
+	for u := range seen {
+		seenList = append(seenList, u)
+	}
+	sort.Strings(seenList)
+	if err := c.save(level, "levels", d); err != nil {
+		return err
+	}
+	return c.save(seenList, "seen", d)
+}
+
+func (c crawlCheckpoint) dropLevel(depth int) error {
+	d := strconv.Itoa(depth)
+	for _, parts := range [][]string{{"levels", d}, {"seen", d}, {"found", d}} {
+		if err := c.cache.Delete(append([]string{"crawls", c.name}, parts...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c crawlCheckpoint) loadFound(depth int, username string) ([]string, error) {
+	var res []string
+	_, err := c.load(&res, "found", strconv.Itoa(depth), username)
+	return res, err
+}
+
+func (c crawlCheckpoint) saveFound(depth int, username string, found []string) error {
+	if len(found) == 0 {
+		return nil
+	}
+	return c.save(found, "found", strconv.Itoa(depth), username)
+}
+
+// nextCrawlLevel returns the users found for the next level, those found from the most users first, keeping
+// at most limit of them unless limit is zero.
+func nextCrawlLevel(next map[string]int, limit int) []string {
+	var res []string
+	for u := range next {
+		res = append(res, u)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if next[res[i]] != next[res[j]] {
+			return next[res[i]] > next[res[j]]
+		}
+		return res[i] < res[j]
+	})
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res
+}
+
+// Crawl reads the followers and following of seeds, then of the users found that way, and so on out to depth
+// hops, storing them as it goes. Each level keeps the users found from the most users in the level before,
+// up to CrawlMaxPerLevel. Deleted users and, with CrawlMaxFollowers, users with more followers than that are
+// skipped rather than expanded. Progress is checkpointed in f's cache under CrawlName, which defaults to the
+// seeds, after every user, so a crawl that's stopped resumes where it left off unless CrawlRestart is set.
+// Errors from ctx being done are returned wrapped, so check for them with errors.Is.
+func Crawl(ctx context.Context, f Factory, seeds []string, cOpts ...CrawlOption) (CrawlStats, error) {
+	opts := MakeCrawlOptions(cOpts...)
+	if opts.FollowersOnly() && opts.FollowingOnly() {
+		return CrawlStats{}, errors.Errorf("crawl either only followers or only following, not both")
+	}
+	depth := or.Int(opts.Depth(), 1)
+	onUser := opts.OnUser()
+	if onUser == nil {
+		onUser = func(u CrawledUser) { log.Printf("crawled: %+v", u) }
+	}
+
+	var uniqueSeeds []string
+	seen := map[string]bool{}
+	for _, s := range seeds {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" && !seen[s] {
+			seen[s] = true
+			uniqueSeeds = append(uniqueSeeds, s)
+		}
+	}
+	if len(uniqueSeeds) == 0 {
+		return CrawlStats{}, errors.Errorf("no users to crawl from")
+	}
+	sort.Strings(uniqueSeeds)
+	name := or.String(opts.Name(), strings.Join(uniqueSeeds, ","))
+
+	cp := crawlCheckpoint{cache: f.Cache(), name: name}
+	if opts.Restart() {
+		if err := cp.cache.Delete("crawls", name); err != nil {
+			return CrawlStats{}, err
+		}
+	}
+	state, err := cp.loadState()
+	if err != nil {
+		return CrawlStats{}, err
+	}
+	var level []string
+	next := map[string]int{}
+	if state == nil {
+		state = &crawlState{Stats: CrawlStats{Found: len(uniqueSeeds)}}
+		level = uniqueSeeds
+		if err := cp.saveLevel(0, level, seen); err != nil {
+			return CrawlStats{}, errors.Wrap(err, "saving checkpoint")
+		}
+	} else if state.Depth >= depth {
+		log.Printf("crawl %s is already done to depth %d; restart it to crawl again", name, state.Depth)
+		return state.Stats, nil
+	} else {
+		if level, seen, err = cp.loadLevel(state.Depth); err != nil {
+			return state.Stats, err
+		}
+		for _, u := range level[:state.Done] {
+			found, err := cp.loadFound(state.Depth, u)
+			if err != nil {
+				return state.Stats, err
+			}
+			for _, other := range found {
+				if !seen[other] {
+					next[other]++
+				}
+			}
+		}
+		log.Printf("resuming crawl %s at depth %d with %d user(s) left in the level", name, state.Depth, len(level)-state.Done)
+	}
+
+	for state.Depth < depth {
+		for state.Done < len(level) {
+			if err := ctx.Err(); err != nil {
+				return state.Stats, err
+			}
+			username := level[state.Done]
+			user, found, err := crawlUser(ctx, f, username, state.Depth, opts)
+			if err != nil {
+				return state.Stats, errors.Wrapf(err, "crawling %s", username)
+			}
+			// Reading followers stops without an error once ctx is done, so what was found may be partial.
+			if err := ctx.Err(); err != nil {
+				return state.Stats, err
+			}
+			if err := cp.saveFound(state.Depth, username, found); err != nil {
+				return state.Stats, errors.Wrap(err, "saving checkpoint")
+			}
+			if user.Skipped != "" {
+				state.Stats.Skipped++
+			} else {
+				state.Stats.Expanded++
+			}
+			for _, u := range found {
+				if !seen[u] {
+					next[u]++
+				}
+			}
+			state.Done++
+			if err := cp.saveState(*state); err != nil {
+				return state.Stats, errors.Wrap(err, "saving checkpoint")
+			}
+			onUser(user)
+		}
+
+		kept := nextCrawlLevel(next, opts.MaxPerLevel())
+		for _, u := range kept {
+			seen[u] = true
+		}
+		log.Printf("crawl %s: depth %d done; found %d user(s) for the next level and kept %d", name, state.Depth, len(next), len(kept))
+		if err := cp.saveLevel(state.Depth+1, kept, seen); err != nil {
+			return state.Stats, errors.Wrap(err, "saving checkpoint")
+		}
+		state.Stats.Found += len(kept)
+		state.Depth++
+		state.Done = 0
+		if err := cp.saveState(*state); err != nil {
+			return state.Stats, errors.Wrap(err, "saving checkpoint")
+		}
+		if err := cp.dropLevel(state.Depth - 1); err != nil {
+			return state.Stats, errors.Wrap(err, "saving checkpoint")
+		}
+		level, next = kept, map[string]int{}
+	}
+	return state.Stats, nil
+}
+
+// crawlUser reads the followers and following of username unless it should be skipped, and returns them.
+func crawlUser(ctx context.Context, f Factory, username string, depth int, opts CrawlOptions) (CrawledUser, []string, error) {
+	res := CrawledUser{Username: username, Depth: depth}
+	u := f.MakeUser(username)
+	if skip, err := u.userInfoStore().GetUserSkip(ctx, username); err != nil {
+		return res, nil, err
+	} else if skip {
+		res.Skipped = "deleted or skipped"
+		return res, nil, nil
+	}
+	// This also notices deleted users and marks them skipped.
+	userInfo, err := u.UserInfo(ctx)
+	if err != nil {
+		return res, nil, err
+	}
+	if !hasUserInfo(userInfo) {
+		res.Skipped = "deleted or unavailable"
+		return res, nil, nil
+	}
+	if limit := opts.MaxFollowers(); limit > 0 && userInfo.Followers() > limit {
+		res.Skipped = fmt.Sprintf("has %d followers", userInfo.Followers())
+		return res, nil, nil
+	}
+
+	// Someone who both follows and is followed by username is only found once.
+	var found []string
+	isFound := map[string]bool{}
+	add := func(users []*User) {
+		for _, other := range users {
+			if name := strings.ToLower(other.Username()); !isFound[name] {
+				isFound[name] = true
+				found = append(found, name)
+			}
+		}
+	}
+	if !opts.FollowingOnly() {
+		users, err := collectUsers(u.Followers(ctx, UserFollowersThreads(opts.Threads())))
+		if err != nil {
+			return res, nil, errors.Wrap(err, "reading followers")
+		}
+		add(users)
+		res.Followers = len(users)
+	}
+	if !opts.FollowersOnly() {
+		users, err := collectUsers(u.Following(ctx, UserFollowingThreads(opts.Threads())))
+		if err != nil {
+			return res, nil, errors.Wrap(err, "reading following")
+		}
+		add(users)
+		res.Following = len(users)
+	}
+	return res, found, nil
+}
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/api/fakegettr"
+)
+
+func TestCrawl(t *testing.T) {
+	ctx := context.Background()
+	var users []api.UserInfo
+	for _, u := range []string{"seed", "a", "b", "c", "d", "e"} {
+		users = append(users, api.UserInfo{Username: u, OUsername: u})
+	}
+	users = append(users, api.UserInfo{Username: "big", OUsername: "big", Flg: 1000})
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Users:        users,
+		DeletedUsers: []string{"gone"},
+		Followers: map[string][]string{
+			"seed": {"a", "b", "big", "gone"},
+			"a":    {"c", "d"},
+			"b":    {"d", "e"},
+			"big":  {"e"},
+		},
+	})
+	defer s.Close()
+	f := MakeMemoryFactory(s.MakeClient("me"))
+	if err := f.Store().SetUserSkip(ctx, "gone", true); err != nil {
+		t.Fatalf("SetUserSkip: %v", err)
+	}
+
+	// The first crawl is stopped after two users and the second resumes it.
+	ctx, cancel := context.WithCancel(ctx)
+	var crawled []CrawledUser
+	opts := []CrawlOption{
+		CrawlDepth(2),
+		CrawlFollowersOnly(true),
+		CrawlMaxFollowers(100),
+		CrawlOnUser(func(u CrawledUser) {
+			crawled = append(crawled, u)
+			if len(crawled) == 2 {
+				cancel()
+			}
+		}),
+	}
+	if _, err := Crawl(ctx, f, []string{"seed"}, opts...); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Crawl: want context.Canceled, got %v", err)
+	}
+	stats, err := Crawl(context.Background(), f, []string{"seed"}, opts...)
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+
+	want := []CrawledUser{
+		{Username: "seed", Depth: 0, Followers: 4},
+		{Username: "a", Depth: 1, Followers: 2},
+		{Username: "b", Depth: 1, Followers: 2},
+		{Username: "big", Depth: 1, Skipped: "has 1000 followers"},
+		{Username: "gone", Depth: 1, Skipped: "deleted or skipped"},
+	}
+	if !reflect.DeepEqual(want, crawled) {
+		t.Errorf("Crawl: want != got:\n%+v\n%+v", want, crawled)
+	}
+	if want := (CrawlStats{Expanded: 3, Skipped: 2, Found: 8}); stats != want {
+		t.Errorf("Crawl: want stats %+v, got %+v", want, stats)
+	}
+
+	// It's done, so running it again reads nothing.
+	before := len(s.Requests())
+	if _, err := Crawl(context.Background(), f, []string{"seed"}, opts...); err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+	if got := len(s.Requests()) - before; got != 0 {
+		t.Errorf("Crawl: want no requests once done, got %d", got)
+	}
+}
+
+// cancelingTransport cancels once a response for path has been read.
+type cancelingTransport struct {
+	next   http.RoundTripper
+	path   string
+	cancel context.CancelFunc
+}
+
+func (t *cancelingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if req.URL.Path == t.path {
+		t.cancel()
+	}
+	return res, err
+}
+
+func TestCrawlCanceledReadingFollowers(t *testing.T) {
+	var followers []string
+	for i := 0; i < 50; i++ {
+		followers = append(followers, fmt.Sprintf("f%02d", i))
+	}
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Users:     []api.UserInfo{{Username: "seed", OUsername: "seed"}},
+		Followers: map[string][]string{"seed": followers},
+	})
+	defer s.Close()
+
+	// The first crawl is stopped after the first page of seed's followers and the second resumes it.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := s.MakeClient("me", api.MakeClientTransport(&cancelingTransport{
+		next:   s.Client().Transport,
+		path:   "/u/user/seed/followers",
+		cancel: cancel,
+	}))
+	var crawled []CrawledUser
+	opts := []CrawlOption{
+		CrawlFollowersOnly(true),
+		CrawlOnUser(func(u CrawledUser) { crawled = append(crawled, u) }),
+	}
+	f := MakeMemoryFactory(client)
+	if _, err := Crawl(ctx, f, []string{"seed"}, opts...); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Crawl: want context.Canceled, got %v", err)
+	}
+	if len(crawled) != 0 {
+		t.Fatalf("Crawl: want no users crawled once canceled, got %+v", crawled)
+	}
+
+	// Canceling again does nothing, so the same client reads the rest.
+	stats, err := Crawl(context.Background(), f, []string{"seed"}, opts...)
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+	if want := []CrawledUser{{Username: "seed", Depth: 0, Followers: 50}}; !reflect.DeepEqual(want, crawled) {
+		t.Errorf("Crawl: want != got:\n%+v\n%+v", want, crawled)
+	}
+	if want := (CrawlStats{Expanded: 1, Found: 51}); stats != want {
+		t.Errorf("Crawl: want stats %+v, got %+v", want, stats)
+	}
+}
+
+func TestCrawlUserMutual(t *testing.T) {
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Users:     []api.UserInfo{{Username: "seed", OUsername: "seed"}},
+		Followers: map[string][]string{"seed": {"a", "b"}},
+		Following: map[string][]string{"seed": {"b", "c"}},
+	})
+	defer s.Close()
+	f := MakeMemoryFactory(s.MakeClient("me"))
+
+	user, found, err := crawlUser(context.Background(), f, "seed", 0, MakeCrawlOptions())
+	if err != nil {
+		t.Fatalf("crawlUser: %v", err)
+	}
+	sort.Strings(found)
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(want, found) {
+		t.Errorf("crawlUser: want != got: %v %v", want, found)
+	}
+	if user.Followers != 2 || user.Following != 2 {
+		t.Errorf("crawlUser: want 2 followers and 2 following, got %+v", user)
+	}
+}
+
+func TestNextCrawlLevel(t *testing.T) {
+	got := nextCrawlLevel(map[string]int{"a": 1, "b": 3, "c": 2, "d": 2}, 3)
+	if want := []string{"b", "c", "d"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("nextCrawlLevel: want != got: %v %v", want, got)
+	}
+}
@@ -2,8 +2,13 @@ package model
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
 	"fmt"
 	"math"
+	"net/url"
+	"os"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -21,6 +26,21 @@ var (
 	dbVerboseFollowers = flags.Bool("db_verbose_followers", "verbose logging for getting and setting followers")
 	dbVerboseFollowing = flags.Bool("db_verbose_following", "verbose logging for getting and setting following")
 	dbVerbosePosts     = flags.Bool("db_verbose_posts", "verbose logging for getting and setting posts")
+	dbVerboseComments  = flags.Bool("db_verbose_comments", "verbose logging for getting and setting comments")
+
+	mongoURI              = flags.String("mongo_uri", "MongoDB connection string, e.g. mongodb://db.example.com:27017/?replicaSet=rs0; defaults to mongodb://localhost:27017")
+	mongoDBName           = flags.String("mongo_db", "MongoDB database; defaults to gettr")
+	mongoUsername         = flags.String("mongo_username", "MongoDB username")
+	mongoPassword         = flags.String("mongo_password", "MongoDB password; defaults to $MONGO_PASSWORD")
+	mongoAuthSource       = flags.String("mongo_auth_source", "database against which --mongo_username is authenticated; defaults to admin")
+	mongoTLS              = flags.Bool("mongo_tls", "connect to MongoDB over TLS")
+	mongoTLSCAFile        = flags.String("mongo_tls_ca_file", "PEM file of CAs used to verify the MongoDB server; implies --mongo_tls")
+	mongoTLSInsecure      = flags.Bool("mongo_tls_insecure", "don't verify the MongoDB server's certificate; implies --mongo_tls")
+	mongoReplicaSet       = flags.String("mongo_replica_set", "name of the MongoDB replica set")
+	mongoConnectTimeout   = flag.Duration("mongo_connect_timeout", 0, "timeout for connecting to MongoDB; zero for the driver's default")
+	mongoOperationTimeout = flag.Duration("mongo_operation_timeout", 0, "timeout for selecting a server and for reads and writes; zero for the driver's default")
+	mongoMinPoolSize      = flags.Int("mongo_min_pool_size", "min connections kept open to each MongoDB server")
+	mongoMaxPoolSize      = flags.Int("mongo_max_pool_size", "max connections open to each MongoDB server; zero for the driver's default")
 )
 
 type DB struct {
@@ -30,16 +50,108 @@ type DB struct {
 	dbVerboseFollowers bool
 	dbVerboseFollowing bool
 	dbVerbosePosts     bool
+	dbVerboseComments  bool
 }
 
+// MakeDBFromFlags connects to the MongoDB given by the --mongo_* flags. mOpts override the flags.
+func MakeDBFromFlags(ctx context.Context, mOpts ...MakeDBOption) (*DB, error) {
+	for _, s := range []int{*mongoMinPoolSize, *mongoMaxPoolSize} {
+		if s < 0 {
+			return nil, errors.Errorf("MongoDB pool sizes can't be negative: %d", s)
+		}
+	}
+	password := *mongoPassword
+	if password == "" {
+		password = os.Getenv("MONGO_PASSWORD")
+	}
+	opts := []MakeDBOption{
+		MakeDBUri(*mongoURI),
+		MakeDBDbName(*mongoDBName),
+		MakeDBUsername(*mongoUsername),
+		MakeDBPassword(password),
+		MakeDBAuthSource(*mongoAuthSource),
+		MakeDBTls(*mongoTLS),
+		MakeDBTlsCAFile(*mongoTLSCAFile),
+		MakeDBTlsInsecure(*mongoTLSInsecure),
+		MakeDBReplicaSet(*mongoReplicaSet),
+		MakeDBConnectTimeout(*mongoConnectTimeout),
+		MakeDBOperationTimeout(*mongoOperationTimeout),
+		MakeDBMinPoolSize(uint64(*mongoMinPoolSize)),
+		MakeDBMaxPoolSize(uint64(*mongoMaxPoolSize)),
+	}
+	return MakeDB(ctx, append(opts, mOpts...)...)
+}
+
+// mongoClientOptions returns the options with which to connect and the URI, with any password redacted, for
+// logging.
+func mongoClientOptions(opts MakeDBOptions) (*options.ClientOptions, string, error) {
+	uri := opts.Uri()
+	if uri == "" {
+		uri = fmt.Sprintf("mongodb://localhost:%d", or.Int(opts.Port(), 27017))
+	} else if opts.Port() != 0 {
+		return nil, "", errors.Errorf("set the port in the URI instead of with MakeDBPort")
+	}
+	redacted := uri
+	if u, err := url.Parse(uri); err == nil {
+		redacted = u.Redacted()
+	}
+
+	res := options.Client().ApplyURI(uri)
+	if err := res.Validate(); err != nil {
+		return nil, "", errors.Errorf("invalid MongoDB URI %s: %v", redacted, err)
+	}
+	if opts.Username() != "" {
+		res.SetAuth(options.Credential{
+			Username:   opts.Username(),
+			Password:   opts.Password(),
+			AuthSource: opts.AuthSource(),
+		})
+	}
+	if opts.Tls() || opts.TlsCAFile() != "" || opts.TlsInsecure() {
+		tlsConfig := &tls.Config{InsecureSkipVerify: opts.TlsInsecure()}
+		if f := opts.TlsCAFile(); f != "" {
+			pem, err := os.ReadFile(f)
+			if err != nil {
+				return nil, "", errors.Errorf("reading CA file: %v", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, "", errors.Errorf("no certificates in CA file %s", f)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		res.SetTLSConfig(tlsConfig)
+	}
+	if rs := opts.ReplicaSet(); rs != "" {
+		res.SetReplicaSet(rs)
+	}
+	if d := opts.ConnectTimeout(); d != 0 {
+		res.SetConnectTimeout(d)
+	}
+	if d := opts.OperationTimeout(); d != 0 {
+		res.SetServerSelectionTimeout(d)
+		res.SetSocketTimeout(d)
+	}
+	if s := opts.MinPoolSize(); s != 0 {
+		res.SetMinPoolSize(s)
+	}
+	if s := opts.MaxPoolSize(); s != 0 {
+		res.SetMaxPoolSize(s)
+	}
+	return res, redacted, nil
+}
+
+// MakeDB connects to the gettr database, creating its indexes. With MakeDBSchemaless it only connects, for
+// using a database other than gettr's, e.g. for work queues.
 func MakeDB(ctx context.Context, mOpts ...MakeDBOption) (*DB, error) {
 	opts := MakeMakeDBOptions(mOpts...)
 
-	port := or.Int(opts.Port(), 27017)
 	dbName := or.String(opts.DbName(), "gettr")
-	uri := fmt.Sprintf("mongodb://localhost:%d", port)
+	clientOptions, uri, err := mongoClientOptions(opts)
+	if err != nil {
+		return nil, err
+	}
 	log.Printf("trying to connect to %s to create %s", uri, dbName)
-	clientOptions := options.Client().ApplyURI(uri)
 
 	// Connect to MongoDB
 	client, err := mongo.Connect(ctx, clientOptions)
@@ -54,12 +166,6 @@ func MakeDB(ctx context.Context, mOpts ...MakeDBOption) (*DB, error) {
 
 	log.Printf("connected to %s", dbName)
 
-	db := client.Database(dbName)
-	db.Collection("userInfo")
-	db.Collection("following")
-	db.Collection("followers")
-	db.Collection("posts")
-
 	res := &DB{
 		dbName:             dbName,
 		client:             client,
@@ -67,16 +173,87 @@ func MakeDB(ctx context.Context, mOpts ...MakeDBOption) (*DB, error) {
 		dbVerboseFollowers: *dbVerboseFollowers,
 		dbVerboseFollowing: *dbVerboseFollowing,
 		dbVerbosePosts:     *dbVerbosePosts,
+		dbVerboseComments:  *dbVerboseComments,
+	}
+	if opts.Schemaless() {
+		return res, nil
+	}
+
+	db := client.Database(dbName)
+	db.Collection("userInfo")
+	db.Collection("following")
+	db.Collection("followers")
+	db.Collection("posts")
+	db.Collection("comments")
+	db.Collection("snapshots")
+	db.Collection("snapshotMembers")
+	db.Collection("userInfoHistory")
+	db.Collection("trackedPosts")
+	db.Collection("postEngagement")
+	db.Collection("watchState")
+
+	if err := res.createIndexes(ctx); err != nil {
+		return nil, err
 	}
 	return res, nil
 }
 
-func (d *DB) database() *mongo.Database {
+func (d *DB) createIndexes(ctx context.Context) error {
+	if _, err := d.collection("posts").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{"username", 1}}},
+		{Keys: bson.D{{"postinfo.uid", 1}}},
+		{Keys: bson.D{{"sharers", 1}}},
+		{Keys: bson.D{{"postinfo.cdate", -1}}},
+	}); err != nil {
+		return errors.Errorf("creating posts indexes: %v", err)
+	}
+	if err := d.createPostIDIndex(ctx); err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		log.Printf("posts has duplicates so post IDs aren't indexed; run DedupePosts to remove them: %v", err)
+	}
+	if err := d.createCommentsIndex(ctx); err != nil {
+		return err
+	}
+	if err := d.createSnapshotIndexes(ctx); err != nil {
+		return err
+	}
+	if err := d.createUserInfoHistoryIndexes(ctx); err != nil {
+		return err
+	}
+	if err := d.createEngagementIndexes(ctx); err != nil {
+		return err
+	}
+	return d.createEntityIndexes(ctx)
+}
+
+func (d *DB) createPostIDIndex(ctx context.Context) error {
+	_, err := d.collection("posts").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"postinfo.id", 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// createCommentsIndex makes comments unique within their post, which AddComments relies on to upsert them.
+func (d *DB) createCommentsIndex(ctx context.Context) error {
+	if _, err := d.collection("comments").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"postid", 1}, {"commentinfo.id", 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return errors.Errorf("creating comments index: %v", err)
+	}
+	return nil
+}
+
+// Database returns the underlying database, for callers that keep their own collections in it.
+func (d *DB) Database() *mongo.Database {
 	return d.client.Database(d.dbName)
 }
 
 func (d *DB) collection(name string) *mongo.Collection {
-	return d.database().Collection(name)
+	return d.Database().Collection(name)
 }
 
 func (d *DB) Disconnect(ctx context.Context) error {
@@ -94,9 +271,20 @@ type storedUserInfo struct {
 	Options  UserOptions
 }
 
-type storedPostInfo struct {
+// StoredPost is a document of the posts collection. Username is whose posts it was last read with, which
+// isn't the author's if they shared it, and Sharers is everyone other than the author it was read with.
+type StoredPost struct {
 	PostInfo api.PostInfo
 	Username string
+	Entities Entities
+	Sharers  []string
+}
+
+// StoredComment is a document of the comments collection.
+type StoredComment struct {
+	CommentInfo api.CommentInfo
+	PostID      string
+	Entities    Entities
 }
 
 func (d *DB) SetUserInfo(ctx context.Context, username string, userInfo api.UserInfo) error {
@@ -233,6 +421,9 @@ func (d *DB) getStoredUserInfo(ctx context.Context, username string) (*storedUse
 
 func (d *DB) GetUserInfo(ctx context.Context, username string) (*api.UserInfo, error) {
 	stored, err := d.getStoredUserInfo(ctx, username)
+	if noUsers(err) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -428,6 +619,37 @@ func (d *DB) GetFollowing(ctx context.Context, username string) (chan string, ch
 	return d.getFollowish(ctx, "following", username)
 }
 
+func (d *DB) GetFollowersShards(ctx context.Context, username string) ([]api.OffsetStrings, error) {
+	return d.getFollowishShards(ctx, "followers", username)
+}
+
+func (d *DB) GetFollowingShards(ctx context.Context, username string) ([]api.OffsetStrings, error) {
+	return d.getFollowishShards(ctx, "following", username)
+}
+
+func (d *DB) getFollowishShards(ctx context.Context, collection, username string) ([]api.OffsetStrings, error) {
+	filter := bson.D{{"username", username}}
+	findOpts := options.Find()
+	findOpts.SetSort(bson.D{{"offset", 1}})
+	cur, err := d.collection(collection).Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, errors.Errorf("%s Find: %v", collection, err)
+	}
+	defer cur.Close(ctx)
+	var res []api.OffsetStrings
+	for cur.Next(ctx) {
+		var el storedFollowish
+		if err := cur.Decode(&el); err != nil {
+			return nil, errors.Errorf("Decode: %v", err)
+		}
+		res = append(res, api.OffsetStrings{Offset: el.Offset, Strings: el.Usernames})
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
 func (d *DB) GetUserMaxFollowerOffset(ctx context.Context, username string) (int, error) {
 	return d.getUserMaxFollowishOffset(ctx, username, "followers")
 }
@@ -475,39 +697,231 @@ func (d *DB) GetFollowersSync(ctx context.Context, username string) ([]string, e
 	return res, nil
 }
 
-func (d *DB) AddPostInfos(ctx context.Context, username string, postInfos []api.PostInfo) error {
+// UpsertCounts is how many documents a write inserted and how many it updated in place.
+type UpsertCounts struct {
+	Inserted int64
+	Updated  int64
+}
+
+// postUpdate is the update that stores p as read with username, adding username to its sharers if someone
+// else wrote it.
+func postUpdate(username string, p api.PostInfo) bson.D {
+	res := bson.D{{"$set", bson.D{
+		{"postinfo", p},
+		{"username", username},
+		{"entities", ExtractEntities(p.Txt, p.Ttl, p.Dsc)},
+	}}}
+	if author := strings.ToLower(p.UID); author != "" && author != strings.ToLower(username) {
+		res = append(res, bson.E{"$addToSet", bson.D{{"sharers", strings.ToLower(username)}}})
+	}
+	return res
+}
+
+// AddPostInfos stores postInfos for username in one bulk write, updating any post already stored with the
+// same ID. Posts written by someone else are recorded as shared by username, along with whoever else they
+// were read with before.
+func (d *DB) AddPostInfos(ctx context.Context, username string, postInfos []api.PostInfo) (UpsertCounts, error) {
+	// Only the last copy of a post is kept, since two upserts of the same ID in one unordered write could both
+	// insert.
+	byID := map[string]int{}
+	var models []mongo.WriteModel
 	for _, p := range postInfos {
-		// TODO: This sucks
-		// filter := bson.D{{"postinfo.id", p.ID}}
-		// if res, err := d.collection("posts").DeleteMany(ctx, filter); err != nil {
-		// 	if d.dbVerbosePosts {
-		// 		log.Printf("AddPostInfos: DeleteMany error: %v", err)
-		// 	}
-		// 	return err
-		// } else {
-		// 	if d.dbVerbosePosts {
-		// 		log.Printf("AddPostInfos: DeleteMany result: %+v", res)
-		// 	}
-		// }
-
-		stored := storedPostInfo{
-			PostInfo: p,
-			Username: username,
-		}
-		res, err := d.collection("posts").InsertOne(ctx, stored)
+		model := mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{"postinfo.id", p.ID}}).
+			SetUpdate(postUpdate(username, p)).
+			SetUpsert(true)
+		if i, ok := byID[p.ID]; ok {
+			models[i] = model
+			continue
+		}
+		byID[p.ID] = len(models)
+		models = append(models, model)
+	}
+	if len(models) == 0 {
+		return UpsertCounts{}, nil
+	}
+	res, err := d.collection("posts").BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return UpsertCounts{}, err
+	}
+	counts := UpsertCounts{Inserted: res.UpsertedCount, Updated: res.MatchedCount}
+	if d.dbVerbosePosts {
+		log.Printf("AddPostInfos(%q, %d posts) -> %+v", username, len(postInfos), counts)
+	}
+	return counts, nil
+}
+
+// GetPostInfos returns the posts stored for username, newest first.
+func (d *DB) GetPostInfos(ctx context.Context, username string) ([]api.PostInfo, error) {
+	filter := bson.D{{"username", username}}
+	findOpts := options.Find()
+	findOpts.SetSort(bson.D{{"postinfo.cdate", -1}})
+	cur, err := d.collection("posts").Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, errors.Errorf("Find: %v", err)
+	}
+	defer cur.Close(ctx)
+	var res []api.PostInfo
+	for cur.Next(ctx) {
+		var el StoredPost
+		if err := cur.Decode(&el); err != nil {
+			return nil, errors.Errorf("Decode: %v", err)
+		}
+		res = append(res, el.PostInfo)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (d *DB) GetLatestPostCDate(ctx context.Context, username string) (api.IntDate, error) {
+	filter := bson.D{{"username", username}}
+	findOpts := options.FindOne().SetSort(bson.D{{"postinfo.cdate", -1}})
+	var el StoredPost
+	if err := d.collection("posts").FindOne(ctx, filter, findOpts).Decode(&el); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, errors.Errorf("FindOne: %v", err)
+	}
+	return el.PostInfo.CDate, nil
+}
+
+func (d *DB) CountPosts(ctx context.Context, cOpts ...CountPostsOption) (int64, error) {
+	opts := MakeCountPostsOptions(cOpts...)
+	filter := opts.Filter()
+	if filter == nil {
+		filter = bson.D{}
+	}
+	return d.collection("posts").CountDocuments(ctx, filter)
+}
+
+// DedupePosts deletes all but one copy of every post stored more than once and then adds the unique index on
+// post ID that MakeDB couldn't create while there were duplicates. It returns the number of posts deleted.
+func (d *DB) DedupePosts(ctx context.Context) (int64, error) {
+	pipeline := mongo.Pipeline{
+		{{"$group", bson.D{
+			{"_id", "$postinfo.id"},
+			{"ids", bson.D{{"$push", "$_id"}}},
+			{"count", bson.D{{"$sum", 1}}},
+		}}},
+		{{"$match", bson.D{{"count", bson.D{{"$gt", 1}}}}}},
+	}
+	cur, err := d.collection("posts").Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return 0, errors.Errorf("Aggregate: %v", err)
+	}
+	defer cur.Close(ctx)
+	var deleted int64
+	for cur.Next(ctx) {
+		var dup struct {
+			IDs []interface{} `bson:"ids"`
+		}
+		if err := cur.Decode(&dup); err != nil {
+			return deleted, errors.Errorf("Decode: %v", err)
+		}
+		filter := bson.D{{"_id", bson.D{{"$in", dup.IDs[1:]}}}}
+		res, err := d.collection("posts").DeleteMany(ctx, filter)
 		if err != nil {
-			return err
+			return deleted, err
 		}
-		if d.dbVerbosePosts {
-			log.Printf("AddPostInfos(%q) -> %+v", p.URI(), res)
+		deleted += res.DeletedCount
+	}
+	if err := cur.Err(); err != nil {
+		return deleted, err
+	}
+	if d.dbVerbosePosts {
+		log.Printf("DedupePosts: deleted %d duplicate posts", deleted)
+	}
+	if err := d.createPostIDIndex(ctx); err != nil {
+		return deleted, err
+	}
+	return deleted, nil
+}
+
+// GetPostIDs returns the ID of every post in the posts collection.
+func (d *DB) GetPostIDs(ctx context.Context) ([]string, error) {
+	ids, err := d.collection("posts").Distinct(ctx, "postinfo.id", bson.D{})
+	if err != nil {
+		return nil, errors.Errorf("Distinct: %v", err)
+	}
+	var res []string
+	for _, id := range ids {
+		if s, ok := id.(string); ok && s != "" {
+			res = append(res, s)
+		}
+	}
+	return res, nil
+}
+
+// AddComments stores comments on postID in one bulk write, replacing any comment already stored with the
+// same ID.
+func (d *DB) AddComments(ctx context.Context, postID string, comments []api.CommentInfo) error {
+	// As with posts, only the last copy of a comment is kept.
+	byID := map[string]int{}
+	var models []mongo.WriteModel
+	for _, c := range comments {
+		stored := StoredComment{
+			CommentInfo: c,
+			PostID:      postID,
+			Entities:    extractCommentEntities(c),
 		}
+		model := mongo.NewReplaceOneModel().
+			SetFilter(bson.D{{"postid", postID}, {"commentinfo.id", c.ID}}).
+			SetReplacement(stored).
+			SetUpsert(true)
+		if i, ok := byID[c.ID]; ok {
+			models[i] = model
+			continue
+		}
+		byID[c.ID] = len(models)
+		models = append(models, model)
+	}
+	if len(models) == 0 {
+		return nil
+	}
+	res, err := d.collection("comments").BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return err
+	}
+	if d.dbVerboseComments {
+		log.Printf("AddComments(%q, %d comments) -> %+v", postID, len(comments), res)
 	}
 	return nil
 }
 
-func (d *DB) CountPosts(ctx context.Context) (int64, error) {
-	filter := bson.D{{}}
-	return d.collection("posts").CountDocuments(ctx, filter)
+// GetComments returns the comments stored for postID, oldest first.
+func (d *DB) GetComments(ctx context.Context, postID string) ([]api.CommentInfo, error) {
+	filter := bson.D{{"postid", postID}}
+	findOpts := options.Find()
+	findOpts.SetSort(bson.D{{"commentinfo.cdate", 1}, {"commentinfo.id", 1}})
+	cur, err := d.collection("comments").Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, errors.Errorf("Find: %v", err)
+	}
+	defer cur.Close(ctx)
+	var res []api.CommentInfo
+	for cur.Next(ctx) {
+		var el StoredComment
+		if err := cur.Decode(&el); err != nil {
+			return nil, errors.Errorf("Decode: %v", err)
+		}
+		res = append(res, el.CommentInfo)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CountComments returns the number of comments stored for postID, or for every post if postID is empty.
+func (d *DB) CountComments(ctx context.Context, postID string) (int64, error) {
+	filter := bson.D{}
+	if postID != "" {
+		filter = bson.D{{"postid", postID}}
+	}
+	return d.collection("comments").CountDocuments(ctx, filter)
 }
 
 func (d *DB) deleteAllUserInfo(ctx context.Context) error {
@@ -521,3 +935,11 @@ func (d *DB) deleteAllFollowers(ctx context.Context) error {
 func (d *DB) deleteAllFollowing(ctx context.Context) error {
 	return d.collection("following").Drop(ctx)
 }
+
+func (d *DB) deleteAllPosts(ctx context.Context) error {
+	return d.collection("posts").Drop(ctx)
+}
+
+func (d *DB) deleteAllComments(ctx context.Context) error {
+	return d.collection("comments").Drop(ctx)
+}
@@ -0,0 +1,266 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/log"
+	"github.com/spudtrooper/goutil/or"
+	"github.com/spudtrooper/goutil/parallel"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TrackedPost is a post whose engagement is sampled by TrackEngagement until Until, or forever if it's zero.
+// Created is when the post was created, once it's been sampled.
+type TrackedPost struct {
+	PostID  string
+	Added   time.Time
+	Until   time.Time
+	Created time.Time
+}
+
+type EngagementSample struct {
+	PostID   string
+	Time     time.Time
+	Comments int
+	Likes    int
+	Shares   int
+}
+
+// EngagementVelocity is how fast a post gained engagement between two samples.
+type EngagementVelocity struct {
+	From, To        EngagementSample
+	Hours           float64
+	CommentsPerHour float64
+	LikesPerHour    float64
+	SharesPerHour   float64
+}
+
+func (d *DB) createEngagementIndexes(ctx context.Context) error {
+	if _, err := d.collection("trackedPosts").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"postid", 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return errors.Errorf("creating trackedPosts index: %v", err)
+	}
+	if _, err := d.collection("postEngagement").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{"postid", 1}, {"time", 1}},
+	}); err != nil {
+		return errors.Errorf("creating postEngagement index: %v", err)
+	}
+	return nil
+}
+
+// TrackPost starts, or extends, sampling the engagement of postID until until.
+func (d *DB) TrackPost(ctx context.Context, postID string, until time.Time) error {
+	filter := bson.D{{"postid", postID}}
+	update := bson.D{
+		{"$set", bson.D{{"until", until}}},
+		{"$setOnInsert", bson.D{{"added", time.Now().UTC().Truncate(time.Millisecond)}}},
+	}
+	if _, err := d.collection("trackedPosts").UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		return errors.Errorf("trackedPosts UpdateOne: %v", err)
+	}
+	return nil
+}
+
+func (d *DB) UntrackPost(ctx context.Context, postID string) error {
+	if _, err := d.collection("trackedPosts").DeleteOne(ctx, bson.D{{"postid", postID}}); err != nil {
+		return errors.Errorf("trackedPosts DeleteOne: %v", err)
+	}
+	return nil
+}
+
+func (d *DB) GetTrackedPost(ctx context.Context, postID string) (*TrackedPost, error) {
+	var res TrackedPost
+	if err := d.collection("trackedPosts").FindOne(ctx, bson.D{{"postid", postID}}).Decode(&res); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, errors.Errorf("trackedPosts FindOne: %v", err)
+	}
+	return &res, nil
+}
+
+// GetTrackedPosts returns the posts still being tracked at now.
+func (d *DB) GetTrackedPosts(ctx context.Context, now time.Time) ([]TrackedPost, error) {
+	filter := bson.D{{"$or", bson.A{
+		bson.D{{"until", time.Time{}}},
+		bson.D{{"until", bson.D{{"$gt", now}}}},
+	}}}
+	cur, err := d.collection("trackedPosts").Find(ctx, filter)
+	if err != nil {
+		return nil, errors.Errorf("trackedPosts Find: %v", err)
+	}
+	var res []TrackedPost
+	if err := cur.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (d *DB) setTrackedPostCreated(ctx context.Context, postID string, created time.Time) error {
+	filter := bson.D{{"postid", postID}}
+	update := bson.D{{"$set", bson.D{{"created", created}}}}
+	if _, err := d.collection("trackedPosts").UpdateOne(ctx, filter, update); err != nil {
+		return errors.Errorf("trackedPosts UpdateOne: %v", err)
+	}
+	return nil
+}
+
+func (d *DB) AddEngagementSample(ctx context.Context, sample EngagementSample) error {
+	sample.Time = sample.Time.UTC().Truncate(time.Millisecond)
+	if _, err := d.collection("postEngagement").InsertOne(ctx, sample); err != nil {
+		return errors.Errorf("postEngagement InsertOne: %v", err)
+	}
+	if d.dbVerbosePosts {
+		log.Printf("AddEngagementSample -> %+v", sample)
+	}
+	return nil
+}
+
+// GetEngagementHistory returns the samples of postID, oldest first.
+func (d *DB) GetEngagementHistory(ctx context.Context, postID string) ([]EngagementSample, error) {
+	findOpts := options.Find().SetSort(bson.D{{"time", 1}})
+	cur, err := d.collection("postEngagement").Find(ctx, bson.D{{"postid", postID}}, findOpts)
+	if err != nil {
+		return nil, errors.Errorf("postEngagement Find: %v", err)
+	}
+	var res []EngagementSample
+	if err := cur.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetEngagementVelocity returns how fast postID gained engagement between since and until, or nil if it hasn't
+// been sampled in that time.
+func (d *DB) GetEngagementVelocity(ctx context.Context, postID string, since, until time.Time) (*EngagementVelocity, error) {
+	tracked, err := d.GetTrackedPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	var created time.Time
+	if tracked != nil {
+		created = tracked.Created
+	}
+	samples, err := d.GetEngagementHistory(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	return engagementVelocity(postID, samples, created, since, until), nil
+}
+
+// engagementVelocity returns the velocity between the samples in effect at since and until. A post has no
+// engagement when it's created, so that's used when since is before the first sample and created is known.
+func engagementVelocity(postID string, samples []EngagementSample, created, since, until time.Time) *EngagementVelocity {
+	var from, to *EngagementSample
+	for i, s := range samples {
+		if s.Time.After(until) {
+			break
+		}
+		if !s.Time.After(since) {
+			from = &samples[i]
+		}
+		to = &samples[i]
+	}
+	if to == nil {
+		return nil
+	}
+	if from == nil {
+		if !created.IsZero() && !created.After(to.Time) {
+			from = &EngagementSample{PostID: postID, Time: created}
+		} else {
+			from = &samples[0]
+		}
+	}
+	res := &EngagementVelocity{From: *from, To: *to, Hours: to.Time.Sub(from.Time).Hours()}
+	if res.Hours > 0 {
+		res.CommentsPerHour = float64(to.Comments-from.Comments) / res.Hours
+		res.LikesPerHour = float64(to.Likes-from.Likes) / res.Hours
+		res.SharesPerHour = float64(to.Shares-from.Shares) / res.Hours
+	}
+	return res
+}
+
+func makeEngagementSample(postID string, details api.PostDetails, t time.Time) EngagementSample {
+	res := EngagementSample{
+		PostID:   postID,
+		Time:     t,
+		Comments: details.ShareInfo.Comments,
+		Likes:    details.ShareInfo.Likes,
+		Shares:   details.ShareInfo.Shares,
+	}
+	// The stats are only in the post when they aren't in aux.
+	if details.ShareInfo == (api.ShareInfo{}) {
+		res.Comments = details.PostInfo.Comments()
+		res.Likes = details.PostInfo.Lkbpst
+		res.Shares = details.PostInfo.Shbpst
+	}
+	return res
+}
+
+// SampleEngagement fetches the post postID and records its engagement now.
+func SampleEngagement(ctx context.Context, client *api.Core, db *DB, postID string) (EngagementSample, error) {
+	details, err := client.GetPostContext(ctx, postID)
+	if err != nil {
+		return EngagementSample{}, err
+	}
+	sample := makeEngagementSample(postID, details, time.Now())
+	if err := db.AddEngagementSample(ctx, sample); err != nil {
+		return EngagementSample{}, err
+	}
+	if details.PostInfo.CDate != 0 {
+		created, _ := details.PostInfo.CDate.Time()
+		if err := db.setTrackedPostCreated(ctx, postID, created.UTC()); err != nil {
+			return EngagementSample{}, err
+		}
+	}
+	return sample, nil
+}
+
+// TrackEngagement samples every tracked post each interval until ctx is done, or just once.
+func TrackEngagement(ctx context.Context, client *api.Core, db *DB, tOpts ...TrackEngagementOption) error {
+	opts := MakeTrackEngagementOptions(tOpts...)
+	interval := opts.Interval()
+	if interval == 0 {
+		interval = 10 * time.Minute
+	}
+	threads := or.Int(opts.Threads(), 10)
+
+	for {
+		posts, err := db.GetTrackedPosts(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		ids := make(chan interface{})
+		go func() {
+			defer close(ids)
+			for _, p := range posts {
+				ids <- p.PostID
+			}
+		}()
+		parallel.ExecAndDrain(ids, threads, func(x interface{}) (interface{}, error) {
+			id := x.(string)
+			if _, err := SampleEngagement(ctx, client, db, id); err != nil {
+				log.Printf("SampleEngagement(%s): %v", id, err)
+				return nil, err
+			}
+			return nil, nil
+		})
+		log.Printf("sampled the engagement of %d tracked post(s)", len(posts))
+
+		if opts.Once() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
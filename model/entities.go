@@ -0,0 +1,254 @@
+package model
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/log"
+	"github.com/spudtrooper/goutil/or"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Entities are the hashtags, mentions and URLs in a post or comment. Hashtags and mentions are lowercased and
+// without their # or @.
+type Entities struct {
+	Hashtags []string
+	Mentions []string
+	URLs     []string
+}
+
+var (
+	urlRE = regexp.MustCompile(`https?://[^\s<>"]+`)
+	// A hashtag or mention has to start a word, so that e.g. emails aren't mentions.
+	hashtagRE = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#])#([\p{L}\p{N}_]+)`)
+	mentionRE = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@([A-Za-z0-9_]+)`)
+)
+
+func normalizeHashtag(h string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(h), "#"))
+}
+
+func appendNew(seen map[string]bool, res []string, s string) []string {
+	if s == "" || seen[s] {
+		return res
+	}
+	seen[s] = true
+	return append(res, s)
+}
+
+// ExtractEntities returns the entities in texts, each once, in the order they first appear.
+func ExtractEntities(texts ...string) Entities {
+	var res Entities
+	seenHashtags, seenMentions, seenURLs := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, text := range texts {
+		for _, u := range urlRE.FindAllString(text, -1) {
+			res.URLs = appendNew(seenURLs, res.URLs, strings.TrimRight(u, ".,;:!?)]}'"))
+		}
+		// URLs go first so that their fragments and paths aren't taken for hashtags and mentions.
+		text = urlRE.ReplaceAllString(text, " ")
+		for _, m := range hashtagRE.FindAllStringSubmatch(text, -1) {
+			res.Hashtags = appendNew(seenHashtags, res.Hashtags, normalizeHashtag(m[1]))
+		}
+		for _, m := range mentionRE.FindAllStringSubmatch(text, -1) {
+			res.Mentions = appendNew(seenMentions, res.Mentions, strings.ToLower(m[1]))
+		}
+	}
+	return res
+}
+
+func extractCommentEntities(c api.CommentInfo) Entities {
+	res := ExtractEntities(c.Text)
+	seen := map[string]bool{}
+	for _, h := range res.Hashtags {
+		seen[h] = true
+	}
+	// GETTR also parses a comment's hashtags, and may find some we don't.
+	for _, h := range c.Hashtags {
+		res.Hashtags = appendNew(seen, res.Hashtags, normalizeHashtag(h))
+	}
+	return res
+}
+
+// entityCollections are where entities are stored, along with the fields holding who wrote each document
+// and when.
+var entityCollections = []struct{ coll, user, cdate string }{
+	{"posts", "username", "postinfo.cdate"},
+	{"comments", "commentinfo.uid", "commentinfo.cdate"},
+}
+
+func (d *DB) createEntityIndexes(ctx context.Context) error {
+	for _, c := range entityCollections {
+		if _, err := d.collection(c.coll).Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{Keys: bson.D{{"entities.hashtags", 1}, {c.cdate, -1}}},
+			{Keys: bson.D{{"entities.mentions", 1}, {c.cdate, -1}}},
+			{Keys: bson.D{{c.user, 1}, {c.cdate, -1}}},
+		}); err != nil {
+			return errors.Errorf("creating %s entity indexes: %v", c.coll, err)
+		}
+	}
+	return nil
+}
+
+// BackfillEntities extracts the entities of posts and comments stored before they were extracted on storing,
+// and returns how many were updated.
+func (d *DB) BackfillEntities(ctx context.Context) (int64, error) {
+	var total int64
+	posts, err := d.backfillEntities(ctx, "posts", func(raw bson.Raw) (Entities, error) {
+		var el StoredPost
+		if err := bson.Unmarshal(raw, &el); err != nil {
+			return Entities{}, err
+		}
+		return ExtractEntities(el.PostInfo.Txt, el.PostInfo.Ttl, el.PostInfo.Dsc), nil
+	})
+	total += posts
+	if err != nil {
+		return total, err
+	}
+	comments, err := d.backfillEntities(ctx, "comments", func(raw bson.Raw) (Entities, error) {
+		var el StoredComment
+		if err := bson.Unmarshal(raw, &el); err != nil {
+			return Entities{}, err
+		}
+		return extractCommentEntities(el.CommentInfo), nil
+	})
+	total += comments
+	return total, err
+}
+
+func (d *DB) backfillEntities(ctx context.Context, coll string, extract func(bson.Raw) (Entities, error)) (int64, error) {
+	const batchSize = 1000
+	cur, err := d.collection(coll).Find(ctx, bson.D{{"entities", bson.D{{"$exists", false}}}})
+	if err != nil {
+		return 0, errors.Errorf("%s Find: %v", coll, err)
+	}
+	defer cur.Close(ctx)
+	var updated int64
+	var models []mongo.WriteModel
+	flush := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+		res, err := d.collection(coll).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return errors.Errorf("%s BulkWrite: %v", coll, err)
+		}
+		updated += res.ModifiedCount
+		models = nil
+		return nil
+	}
+	for cur.Next(ctx) {
+		entities, err := extract(cur.Current)
+		if err != nil {
+			return updated, errors.Errorf("decoding %s: %v", coll, err)
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{"_id", cur.Current.Lookup("_id")}}).
+			SetUpdate(bson.D{{"$set", bson.D{{"entities", entities}}}}))
+		if len(models) == batchSize {
+			if err := flush(); err != nil {
+				return updated, err
+			}
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return updated, err
+	}
+	if err := flush(); err != nil {
+		return updated, err
+	}
+	if d.dbVerbosePosts {
+		log.Printf("backfillEntities(%q) -> %d", coll, updated)
+	}
+	return updated, nil
+}
+
+// TermCount is how many posts and comments had a hashtag or mention.
+type TermCount struct {
+	Term  string
+	Count int
+}
+
+// TopHashtags returns the hashtags in the most posts and comments, most first.
+func (d *DB) TopHashtags(ctx context.Context, tOpts ...TopTermsOption) ([]TermCount, error) {
+	return d.topTerms(ctx, "hashtags", MakeTopTermsOptions(tOpts...))
+}
+
+// TopMentions returns the users mentioned in the most posts and comments, most first.
+func (d *DB) TopMentions(ctx context.Context, tOpts ...TopTermsOption) ([]TermCount, error) {
+	return d.topTerms(ctx, "mentions", MakeTopTermsOptions(tOpts...))
+}
+
+func (d *DB) topTerms(ctx context.Context, field string, opts TopTermsOptions) ([]TermCount, error) {
+	counts := map[string]int{}
+	for _, c := range entityCollections {
+		if opts.PostsOnly() && c.coll != "posts" {
+			continue
+		}
+		match := bson.D{}
+		if opts.Username() != "" {
+			match = append(match, bson.E{c.user, opts.Username()})
+		}
+		if cdate := CDateRange(opts.Since(), opts.Until()); len(cdate) > 0 {
+			match = append(match, bson.E{c.cdate, cdate})
+		}
+		path := "$entities." + field
+		pipeline := mongo.Pipeline{
+			{{"$match", match}},
+			{{"$unwind", path}},
+			{{"$group", bson.D{{"_id", path}, {"count", bson.D{{"$sum", 1}}}}}},
+		}
+		cur, err := d.collection(c.coll).Aggregate(ctx, pipeline)
+		if err != nil {
+			return nil, errors.Errorf("%s Aggregate: %v", c.coll, err)
+		}
+		var groups []struct {
+			Term  string `bson:"_id"`
+			Count int
+		}
+		if err := cur.All(ctx, &groups); err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			counts[g.Term] += g.Count
+		}
+	}
+	return topCounts(counts, or.Int(opts.Limit(), 20)), nil
+}
+
+// CDateRange is the filter of a stored post or comment's cdate between since, inclusive, and until,
+// exclusive, either of which may be zero.
+func CDateRange(since, until time.Time) bson.D {
+	res := bson.D{}
+	if !since.IsZero() {
+		res = append(res, bson.E{"$gte", since.UnixNano() / int64(time.Millisecond)})
+	}
+	if !until.IsZero() {
+		res = append(res, bson.E{"$lt", until.UnixNano() / int64(time.Millisecond)})
+	}
+	return res
+}
+
+// topCounts returns the limit terms with the highest counts, breaking ties alphabetically.
+func topCounts(counts map[string]int, limit int) []TermCount {
+	var res []TermCount
+	for t, n := range counts {
+		res = append(res, TermCount{Term: t, Count: n})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Count != res[j].Count {
+			return res[i].Count > res[j].Count
+		}
+		return res[i].Term < res[j].Term
+	})
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res
+}
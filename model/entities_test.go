@@ -0,0 +1,58 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractEntities(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		want  Entities
+	}{
+		{
+			name:  "empty",
+			texts: []string{""},
+		},
+		{
+			name:  "hashtags and mentions",
+			texts: []string{"#MAGA and #maga with @Foo_1, (@bar) #日本"},
+			want: Entities{
+				Hashtags: []string{"maga", "日本"},
+				Mentions: []string{"foo_1", "bar"},
+			},
+		},
+		{
+			name:  "urls",
+			texts: []string{"see https://example.com/a#frag, and http://x.com/@user."},
+			want:  Entities{URLs: []string{"https://example.com/a#frag", "http://x.com/@user"}},
+		},
+		{
+			name:  "not entities",
+			texts: []string{"foo@example.com a#b &#39; C# ##"},
+		},
+		{
+			name:  "several texts",
+			texts: []string{"#a @b", "#A @c"},
+			want: Entities{
+				Hashtags: []string{"a"},
+				Mentions: []string{"b", "c"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ExtractEntities(test.texts...); !reflect.DeepEqual(test.want, got) {
+				t.Errorf("ExtractEntities: want != got: %+v %+v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestTopCounts(t *testing.T) {
+	got := topCounts(map[string]int{"a": 1, "b": 3, "c": 1, "d": 2}, 3)
+	if want := []TermCount{{"b", 3}, {"d", 2}, {"a", 1}}; !reflect.DeepEqual(want, got) {
+		t.Errorf("topCounts: want != got: %v %v", want, got)
+	}
+}
@@ -2,8 +2,10 @@ package model
 
 import (
 	"context"
+	"flag"
 	"sync"
 
+	"github.com/pkg/errors"
 	"github.com/spudtrooper/gettr/api"
 	"github.com/spudtrooper/goutil/flags"
 )
@@ -14,13 +16,16 @@ var (
 	followingUsingDiskCache = flags.Bool("following_using_disk_cache", "use disk cache for followers")
 	verboseCacheHits        = flags.Bool("verbose_cache_hits", "log cache hits verbosely")
 	verbosePersist          = flags.Bool("verbose_persist", "log persisting verbosely")
+	storeBackend            = flag.String("store", "mongo", "where to keep what we read: mongo, sqlite or disk")
 )
 
 type Factory interface {
 	MakeUser(username string) *User
 	Cache() Cache
 	Client() *api.Extended
+	// DB returns the MongoDB store, or nil when --store is something else.
 	DB() *DB
+	Store() Store
 	Self() *User
 }
 
@@ -36,6 +41,8 @@ type factory struct {
 	cache       Cache
 	client      *api.Extended
 	db          *DB
+	store       Store
+	diskStore   Store
 	userCacheMu sync.Mutex
 	userCache   map[string]*User
 	factoryOptions
@@ -46,29 +53,76 @@ func (f *factory) Self() *User           { return f.MakeUser(f.client.Username()
 func (f *factory) Cache() Cache          { return f.cache }
 func (f *factory) Client() *api.Extended { return f.client }
 func (f *factory) DB() *DB               { return f.db }
+func (f *factory) Store() Store          { return f.store }
+
+func (f *factory) userInfoStore() Store {
+	if f.userInfoUsingDiskCache {
+		return f.diskStore
+	}
+	return f.store
+}
+
+func (f *factory) followersStore(fromDisk bool) Store {
+	if fromDisk || f.followersUsingDiskCache {
+		return f.diskStore
+	}
+	return f.store
+}
+
+func (f *factory) followingStore(fromDisk bool) Store {
+	if fromDisk || f.followingUsingDiskCache {
+		return f.diskStore
+	}
+	return f.store
+}
+
+func makeStore(ctx context.Context, cache Cache) (Store, *DB, error) {
+	switch *storeBackend {
+	case "mongo", "":
+		db, err := MakeDBFromFlags(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		return db, db, nil
+	case "sqlite":
+		db, err := MakeSQLiteDBFromFlags(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		return db, nil, nil
+	case "disk":
+		return MakeCacheStore(cache), nil, nil
+	}
+	return nil, nil, errors.Errorf("invalid --store %q: must be mongo, sqlite or disk", *storeBackend)
+}
 
 func MakeFactory(ctx context.Context, cache Cache, client *api.Core) (Factory, error) {
-	db, err := MakeDB(ctx)
+	store, db, err := makeStore(ctx, cache)
 	if err != nil {
 		return nil, err
 	}
-	userCache := map[string]*User{}
-	res := &factory{
-		cache:     cache,
-		client:    api.MakeExtended(client),
-		db:        db,
-		userCache: userCache,
-		factoryOptions: factoryOptions{
-			userInfoUsingDiskCache:  *userInfoUsingDiskCache,
-			followersUsingDiskCache: *followersUsingDiskCache,
-			followingUsingDiskCache: *followingUsingDiskCache,
-			verboseCacheHits:        *verboseCacheHits,
-			verbosePersist:          *verbosePersist,
-		},
-	}
+	res := makeFactory(cache, client, store, db, factoryOptions{
+		userInfoUsingDiskCache:  *userInfoUsingDiskCache,
+		followersUsingDiskCache: *followersUsingDiskCache,
+		followingUsingDiskCache: *followingUsingDiskCache,
+		verboseCacheHits:        *verboseCacheHits,
+		verbosePersist:          *verbosePersist,
+	})
 	return res, nil
 }
 
+func makeFactory(cache Cache, client *api.Core, store Store, db *DB, opts factoryOptions) *factory {
+	return &factory{
+		cache:          cache,
+		client:         api.MakeExtended(client),
+		db:             db,
+		store:          store,
+		diskStore:      MakeCacheStore(cache),
+		userCache:      map[string]*User{},
+		factoryOptions: opts,
+	}
+}
+
 func MakeFactoryFromFlags(ctx context.Context) (Factory, error) {
 	client, err := api.MakeClientFromFlags()
 	if err != nil {
@@ -0,0 +1,324 @@
+package graph
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatJSON    Format = "json"
+	FormatGraphML Format = "graphml"
+	FormatGEXF    Format = "gexf"
+	FormatDOT     Format = "dot"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "":
+		return FormatCSV, nil
+	case FormatCSV, FormatJSON, FormatGraphML, FormatGEXF, FormatDOT:
+		return f, nil
+	}
+	return "", errors.Errorf("invalid graph format %q: want csv, json, graphml, gexf or dot", s)
+}
+
+// FormatForFile returns the format named by filename's extension, e.g. foo.gexf, defaulting to CSV.
+func FormatForFile(filename string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(filename), ".")
+	if ext == "gv" {
+		return FormatDOT, nil
+	}
+	return ParseFormat(ext)
+}
+
+// Write writes g to w in format. CSV is an edge list with a header that most graph tools can import, and JSON
+// has the nodes and the edges. GraphML, GEXF and DOT also have the info of users that have it.
+func (g *Graph) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatCSV, "":
+		return g.writeCSV(w)
+	case FormatJSON:
+		return g.writeJSON(w)
+	case FormatGraphML:
+		return g.writeGraphML(w)
+	case FormatGEXF:
+		return g.writeGEXF(w)
+	case FormatDOT:
+		return g.writeDOT(w)
+	}
+	return errors.Errorf("invalid graph format %q", format)
+}
+
+func (g *Graph) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"source", "target", "kind", "weight"}); err != nil {
+		return err
+	}
+	for _, e := range g.Edges() {
+		if err := cw.Write([]string{e.From, e.To, string(e.Kind), strconv.Itoa(e.Weight)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (g *Graph) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Nodes []string `json:"nodes"`
+		Edges []Edge   `json:"edges"`
+	}{
+		Nodes: append([]string{}, g.Nodes()...),
+		Edges: append([]Edge{}, g.Edges()...),
+	})
+}
+
+// attr is an attribute of nodes or edges, with the type it has in GraphML and GEXF.
+type attr struct {
+	name, typ string
+}
+
+var (
+	nodeAttrs = []attr{
+		{"nickname", "string"},
+		{"followers", "int"},
+		{"following", "int"},
+		{"twitter_followers", "int"},
+		{"twitter_following", "int"},
+		{"cdate", "string"},
+	}
+	edgeAttrs = []attr{
+		{"kind", "string"},
+		{"weight", "int"},
+	}
+)
+
+// nodeValues returns the values of nodeAttrs for username, or nil if we don't have their info.
+func (g *Graph) nodeValues(username string) []string {
+	info, ok := g.infos[username]
+	if !ok {
+		return nil
+	}
+	var cdate string
+	if info.CDate != 0 {
+		t, _ := info.CDate.Time()
+		cdate = t.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		info.Nickname,
+		strconv.Itoa(info.Followers()),
+		strconv.Itoa(info.Following()),
+		strconv.Itoa(info.TwitterFollowers()),
+		strconv.Itoa(info.TwitterFollowing()),
+		cdate,
+	}
+}
+
+func edgeValues(e Edge) []string {
+	return []string{string(e.Kind), strconv.Itoa(e.Weight)}
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (g *Graph) writeGraphML(w io.Writer) error {
+	type key struct {
+		ID       string `xml:"id,attr"`
+		For      string `xml:"for,attr"`
+		AttrName string `xml:"attr.name,attr"`
+		AttrType string `xml:"attr.type,attr"`
+	}
+	type data struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	type node struct {
+		ID   string `xml:"id,attr"`
+		Data []data `xml:"data"`
+	}
+	type edge struct {
+		Source string `xml:"source,attr"`
+		Target string `xml:"target,attr"`
+		Data   []data `xml:"data"`
+	}
+	type graph struct {
+		ID          string `xml:"id,attr"`
+		EdgeDefault string `xml:"edgedefault,attr"`
+		Nodes       []node `xml:"node"`
+		Edges       []edge `xml:"edge"`
+	}
+	type graphML struct {
+		XMLName xml.Name `xml:"graphml"`
+		XMLNS   string   `xml:"xmlns,attr"`
+		Keys    []key    `xml:"key"`
+		Graph   graph    `xml:"graph"`
+	}
+	res := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Graph: graph{ID: "gettr", EdgeDefault: "directed"},
+	}
+	for _, a := range nodeAttrs {
+		res.Keys = append(res.Keys, key{ID: a.name, For: "node", AttrName: a.name, AttrType: a.typ})
+	}
+	for _, a := range edgeAttrs {
+		res.Keys = append(res.Keys, key{ID: a.name, For: "edge", AttrName: a.name, AttrType: a.typ})
+	}
+	for _, n := range g.Nodes() {
+		el := node{ID: n}
+		for i, v := range g.nodeValues(n) {
+			if v != "" {
+				el.Data = append(el.Data, data{Key: nodeAttrs[i].name, Value: v})
+			}
+		}
+		res.Graph.Nodes = append(res.Graph.Nodes, el)
+	}
+	for _, e := range g.Edges() {
+		el := edge{Source: e.From, Target: e.To}
+		for i, v := range edgeValues(e) {
+			el.Data = append(el.Data, data{Key: edgeAttrs[i].name, Value: v})
+		}
+		res.Graph.Edges = append(res.Graph.Edges, el)
+	}
+	return writeXML(w, res)
+}
+
+func (g *Graph) writeGEXF(w io.Writer) error {
+	type attribute struct {
+		ID    string `xml:"id,attr"`
+		Title string `xml:"title,attr"`
+		Type  string `xml:"type,attr"`
+	}
+	type attributes struct {
+		Class      string      `xml:"class,attr"`
+		Attributes []attribute `xml:"attribute"`
+	}
+	type attvalue struct {
+		For   string `xml:"for,attr"`
+		Value string `xml:"value,attr"`
+	}
+	type attvalues struct {
+		Values []attvalue `xml:"attvalue"`
+	}
+	type node struct {
+		ID        string     `xml:"id,attr"`
+		Label     string     `xml:"label,attr"`
+		AttValues *attvalues `xml:"attvalues,omitempty"`
+	}
+	type edge struct {
+		ID        string    `xml:"id,attr"`
+		Source    string    `xml:"source,attr"`
+		Target    string    `xml:"target,attr"`
+		Weight    int       `xml:"weight,attr"`
+		AttValues attvalues `xml:"attvalues"`
+	}
+	type graph struct {
+		DefaultEdgeType string       `xml:"defaultedgetype,attr"`
+		Attributes      []attributes `xml:"attributes"`
+		Nodes           []node       `xml:"nodes>node"`
+		Edges           []edge       `xml:"edges>edge"`
+	}
+	type gexf struct {
+		XMLName xml.Name `xml:"gexf"`
+		XMLNS   string   `xml:"xmlns,attr"`
+		Version string   `xml:"version,attr"`
+		Graph   graph    `xml:"graph"`
+	}
+	res := gexf{
+		XMLNS:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Graph:   graph{DefaultEdgeType: "directed"},
+	}
+	nodeAttributes := attributes{Class: "node"}
+	for _, a := range nodeAttrs {
+		typ := a.typ
+		if typ == "int" {
+			typ = "integer"
+		}
+		nodeAttributes.Attributes = append(nodeAttributes.Attributes, attribute{ID: a.name, Title: a.name, Type: typ})
+	}
+	// Weight is built into GEXF edges, so only the kind is an attribute.
+	edgeAttributes := attributes{Class: "edge", Attributes: []attribute{{ID: "kind", Title: "kind", Type: "string"}}}
+	res.Graph.Attributes = []attributes{nodeAttributes, edgeAttributes}
+	for _, n := range g.Nodes() {
+		el := node{ID: n, Label: n}
+		if values := g.nodeValues(n); values != nil {
+			el.AttValues = &attvalues{}
+			for i, v := range values {
+				if v != "" {
+					el.AttValues.Values = append(el.AttValues.Values, attvalue{For: nodeAttrs[i].name, Value: v})
+				}
+			}
+		}
+		res.Graph.Nodes = append(res.Graph.Nodes, el)
+	}
+	for i, e := range g.Edges() {
+		res.Graph.Edges = append(res.Graph.Edges, edge{
+			ID:        strconv.Itoa(i),
+			Source:    e.From,
+			Target:    e.To,
+			Weight:    e.Weight,
+			AttValues: attvalues{Values: []attvalue{{For: "kind", Value: string(e.Kind)}}},
+		})
+	}
+	return writeXML(w, res)
+}
+
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+	return `"` + r.Replace(s) + `"`
+}
+
+func dotAttrs(attrs []attr, values []string) string {
+	var res []string
+	for i, v := range values {
+		if v == "" {
+			continue
+		}
+		if attrs[i].typ != "int" {
+			v = dotQuote(v)
+		}
+		res = append(res, attrs[i].name+"="+v)
+	}
+	if len(res) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(res, ", ") + "]"
+}
+
+func (g *Graph) writeDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph gettr {\n")
+	for _, n := range g.Nodes() {
+		fmt.Fprintf(&b, "  %s%s;\n", dotQuote(n), dotAttrs(nodeAttrs, g.nodeValues(n)))
+	}
+	for _, e := range g.Edges() {
+		fmt.Fprintf(&b, "  %s -> %s%s;\n", dotQuote(e.From), dotQuote(e.To), dotAttrs(edgeAttrs, edgeValues(e)))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,134 @@
+package graph
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spudtrooper/gettr/model"
+	"github.com/spudtrooper/goutil/or"
+	"github.com/spudtrooper/goutil/parallel"
+)
+
+// BuildFollows builds the graph of who follows whom among the users within depth hops of seed, along with
+// their info. Only what's stored is used, so the walk stops at users whose followers and following haven't
+// been read, and users whose info hasn't been read have none. With FollowsMaxNodes, users past the cap are
+// left out along with their edges.
+func BuildFollows(ctx context.Context, f model.Factory, seed string, fOpts ...FollowsOption) (*Graph, error) {
+	opts := MakeFollowsOptions(fOpts...)
+	depth := or.Int(opts.Depth(), 1)
+	g := New()
+	seed = strings.ToLower(seed)
+	g.AddNode(seed)
+
+	// add adds username unless it's past the cap, and returns whether it's in the graph and whether it's new.
+	add := func(username string) (bool, bool) {
+		if g.nodes[username] {
+			return true, false
+		}
+		if limit := opts.MaxNodes(); limit > 0 && len(g.nodes) >= limit {
+			return false, false
+		}
+		g.AddNode(username)
+		return true, true
+	}
+
+	level := []string{seed}
+	for d := 0; d < depth && len(level) > 0; d++ {
+		var next []string
+		for _, username := range level {
+			u := f.MakeUser(username)
+			if !opts.FollowingOnly() {
+				followers, err := usernames(u.Followers(ctx,
+					model.UserFollowersStoredOnly(true),
+					model.UserFollowersThreads(opts.Threads())))
+				if err != nil {
+					return nil, err
+				}
+				for _, other := range followers {
+					if in, isNew := add(other); in {
+						g.Add(other, username, Follow)
+						if isNew {
+							next = append(next, other)
+						}
+					}
+				}
+			}
+			if !opts.FollowersOnly() {
+				following, err := usernames(u.Following(ctx,
+					model.UserFollowingStoredOnly(true),
+					model.UserFollowingThreads(opts.Threads())))
+				if err != nil {
+					return nil, err
+				}
+				for _, other := range following {
+					if in, isNew := add(other); in {
+						g.Add(username, other, Follow)
+						if isNew {
+							next = append(next, other)
+						}
+					}
+				}
+			}
+		}
+		level = next
+	}
+
+	if err := setUserInfos(ctx, f, g, or.Int(opts.Threads(), 10)); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// usernames reads the usernames of users in order, so that which are left out by a cap doesn't depend on the
+// order they were read in, or returns the first error.
+func usernames(users chan *model.User, errs chan error) ([]string, error) {
+	var res []string
+	var firstErr error
+	parallel.WaitFor(func() {
+		for u := range users {
+			res = append(res, strings.ToLower(u.Username()))
+		}
+	}, func() {
+		for err := range errs {
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	})
+	sort.Strings(res)
+	return res, firstErr
+}
+
+func setUserInfos(ctx context.Context, f model.Factory, g *Graph, threads int) error {
+	nodes := make(chan string)
+	go func() {
+		defer close(nodes)
+		for _, n := range g.Nodes() {
+			nodes <- n
+		}
+	}()
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range nodes {
+				info, err := f.MakeUser(n).UserInfo(ctx, model.UserInfoStoredOnly(true))
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				if err == nil && info.OUsername != "" {
+					g.infos[n] = info
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,130 @@
+// Package graph builds graphs of who interacts with whom on GETTR from what's stored.
+package graph
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spudtrooper/gettr/api"
+)
+
+type EdgeKind string
+
+const (
+	// Reply is from a commenter to the author of what they commented on.
+	Reply EdgeKind = "reply"
+	// Mention is from the author of a post or comment to someone they mentioned in it.
+	Mention EdgeKind = "mention"
+	// Share is from a user to the author of a post they shared.
+	Share EdgeKind = "share"
+	// Follow is from a follower to who they follow.
+	Follow EdgeKind = "follow"
+)
+
+// Edge is how many times From interacted with To in one way.
+type Edge struct {
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+	Kind   EdgeKind `json:"kind"`
+	Weight int      `json:"weight"`
+}
+
+type edgeKey struct {
+	from, to string
+	kind     EdgeKind
+}
+
+// Graph is a directed graph of users with an edge of each kind weighted by how many times one user interacted
+// with another that way. Users may also have their info, which is written along with them.
+type Graph struct {
+	weights map[edgeKey]int
+	nodes   map[string]bool
+	infos   map[string]api.UserInfo
+}
+
+func New() *Graph {
+	return &Graph{
+		weights: map[edgeKey]int{},
+		nodes:   map[string]bool{},
+		infos:   map[string]api.UserInfo{},
+	}
+}
+
+// AddNode adds a user, which needn't have any edges.
+func (g *Graph) AddNode(username string) {
+	if username = strings.ToLower(username); username != "" {
+		g.nodes[username] = true
+	}
+}
+
+// SetUserInfo adds a user along with their info.
+func (g *Graph) SetUserInfo(username string, userInfo api.UserInfo) {
+	g.AddNode(username)
+	g.infos[strings.ToLower(username)] = userInfo
+}
+
+// UserInfo returns the info of a user, if it was set.
+func (g *Graph) UserInfo(username string) (api.UserInfo, bool) {
+	res, ok := g.infos[strings.ToLower(username)]
+	return res, ok
+}
+
+// Add adds one interaction, ignoring users' interactions with themselves.
+func (g *Graph) Add(from, to string, kind EdgeKind) {
+	from, to = strings.ToLower(from), strings.ToLower(to)
+	if from == "" || to == "" || from == to {
+		return
+	}
+	g.nodes[from], g.nodes[to] = true, true
+	g.weights[edgeKey{from, to, kind}]++
+}
+
+// Edges returns the edges ordered by From, To and Kind.
+func (g *Graph) Edges() []Edge {
+	var res []Edge
+	for k, w := range g.weights {
+		res = append(res, Edge{From: k.from, To: k.to, Kind: k.kind, Weight: w})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Kind < b.Kind
+	})
+	return res
+}
+
+// Nodes returns the users, in order.
+func (g *Graph) Nodes() []string {
+	var res []string
+	for n := range g.nodes {
+		res = append(res, n)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Touching returns the subgraph of edges to or from any of users.
+func (g *Graph) Touching(users []string) *Graph {
+	keep := map[string]bool{}
+	for _, u := range users {
+		keep[strings.ToLower(u)] = true
+	}
+	res := New()
+	for k, w := range g.weights {
+		if keep[k.from] || keep[k.to] {
+			res.weights[k] = w
+			res.nodes[k.from], res.nodes[k.to] = true, true
+		}
+	}
+	for n, info := range g.infos {
+		if res.nodes[n] {
+			res.infos[n] = info
+		}
+	}
+	return res
+}
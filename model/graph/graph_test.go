@@ -0,0 +1,281 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/api/fakegettr"
+	"github.com/spudtrooper/gettr/model"
+)
+
+func TestGraph(t *testing.T) {
+	g := New()
+	g.Add("a", "B", Mention)
+	g.Add("A", "b", Mention)
+	g.Add("a", "b", Reply)
+	g.Add("c", "a", Share)
+	g.Add("c", "c", Mention)
+	g.Add("", "c", Reply)
+
+	want := []Edge{
+		{From: "a", To: "b", Kind: Mention, Weight: 2},
+		{From: "a", To: "b", Kind: Reply, Weight: 1},
+		{From: "c", To: "a", Kind: Share, Weight: 1},
+	}
+	if got := g.Edges(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Edges: want != got: %v %v", want, got)
+	}
+	if want, got := []string{"a", "b", "c"}, g.Nodes(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Nodes: want != got: %v %v", want, got)
+	}
+	if want, got := []Edge{{From: "c", To: "a", Kind: Share, Weight: 1}}, g.Touching([]string{"C"}).Edges(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Touching: want != got: %v %v", want, got)
+	}
+
+	var buf bytes.Buffer
+	if err := g.Write(&buf, FormatCSV); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if want, got := "source,target,kind,weight\na,b,mention,2\na,b,reply,1\nc,a,share,1\n", buf.String(); want != got {
+		t.Errorf("Write: want != got: %q %q", want, got)
+	}
+}
+
+func TestBuildInteractions(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := model.MakeDB(ctx, model.MakeDBDbName("gettrtest"))
+	if err != nil {
+		t.Fatalf("MakeDB: %v", err)
+	}
+	defer db.Disconnect(ctx)
+	for _, c := range []string{"posts", "comments"} {
+		if err := db.Database().Collection(c).Drop(ctx); err != nil {
+			t.Fatalf("Drop: %v", err)
+		}
+	}
+
+	if _, err := db.AddPostInfos(ctx, "foo", []api.PostInfo{
+		{ID: "p1", UID: "foo", Txt: "hi @bar"},
+		{ID: "p2", UID: "baz", Txt: "shared"},
+	}); err != nil {
+		t.Fatalf("AddPostInfos: %v", err)
+	}
+	if err := db.AddComments(ctx, "p1", []api.CommentInfo{
+		{ID: "c1", UID: "bar", PUID: "foo", Text: "hey @qux"},
+		{ID: "c2", UID: "qux", Text: "no parent"},
+	}); err != nil {
+		t.Fatalf("AddComments: %v", err)
+	}
+
+	g, err := BuildInteractions(ctx, db)
+	if err != nil {
+		t.Fatalf("BuildInteractions: %v", err)
+	}
+	want := []Edge{
+		{From: "bar", To: "foo", Kind: Reply, Weight: 1},
+		{From: "bar", To: "qux", Kind: Mention, Weight: 1},
+		{From: "foo", To: "bar", Kind: Mention, Weight: 1},
+		{From: "foo", To: "baz", Kind: Share, Weight: 1},
+		{From: "qux", To: "foo", Kind: Reply, Weight: 1},
+	}
+	if got := g.Edges(); !reflect.DeepEqual(want, got) {
+		t.Errorf("BuildInteractions: want != got: %v %v", want, got)
+	}
+
+	g, err = BuildInteractions(ctx, db, BuildUsernames([]string{"baz"}))
+	if err != nil {
+		t.Fatalf("BuildInteractions: %v", err)
+	}
+	if want, got := []Edge{{From: "foo", To: "baz", Kind: Share, Weight: 1}}, g.Edges(); !reflect.DeepEqual(want, got) {
+		t.Errorf("BuildInteractions for baz: want != got: %v %v", want, got)
+	}
+}
+
+func TestBuildInteractionsShares(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := model.MakeDB(ctx, model.MakeDBDbName("gettrtest"))
+	if err != nil {
+		t.Fatalf("MakeDB: %v", err)
+	}
+	defer db.Disconnect(ctx)
+
+	add := func(username string, p api.PostInfo) {
+		if _, err := db.AddPostInfos(ctx, username, []api.PostInfo{p}); err != nil {
+			t.Fatalf("AddPostInfos: %v", err)
+		}
+	}
+	for _, test := range []struct {
+		name    string
+		store   func(p api.PostInfo)
+		sharers []string
+	}{
+		{
+			name: "sharer first",
+			store: func(p api.PostInfo) {
+				add("bar", p)
+				add("foo", p)
+			},
+			sharers: []string{"bar"},
+		},
+		{
+			name: "author first",
+			store: func(p api.PostInfo) {
+				add("foo", p)
+				add("bar", p)
+			},
+			sharers: []string{"bar"},
+		},
+		{
+			name: "author between sharers",
+			store: func(p api.PostInfo) {
+				add("bar", p)
+				add("foo", p)
+				add("Baz", p)
+				add("bar", p)
+			},
+			sharers: []string{"bar", "baz"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := db.Database().Collection("posts").Drop(ctx); err != nil {
+				t.Fatalf("Drop: %v", err)
+			}
+			test.store(api.PostInfo{ID: "p1", UID: "foo", Txt: "hi"})
+
+			g, err := BuildInteractions(ctx, db)
+			if err != nil {
+				t.Fatalf("BuildInteractions: %v", err)
+			}
+			var want []Edge
+			for _, s := range test.sharers {
+				want = append(want, Edge{From: s, To: "foo", Kind: Share, Weight: 1})
+			}
+			if got := g.Edges(); !reflect.DeepEqual(want, got) {
+				t.Errorf("BuildInteractions: want != got: %v %v", want, got)
+			}
+		})
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	g := New()
+	g.SetUserInfo("foo", api.UserInfo{OUsername: "foo", Nickname: `Foo "F" <&>`, Flg: 2, Flw: 1, CDate: 1646092800000})
+	g.AddNode("lonely")
+	g.Add("bar", "foo", Follow)
+
+	var buf bytes.Buffer
+	if err := g.Write(&buf, FormatDOT); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	want := `digraph gettr {
+  "bar";
+  "foo" [nickname="Foo \"F\" <&>", followers=2, following=1, twitter_followers=0, twitter_following=0, cdate="2022-03-01T00:00:00Z"];
+  "lonely";
+  "bar" -> "foo" [kind="follow", weight=1];
+}
+`
+	if got := buf.String(); want != got {
+		t.Errorf("Write dot: want != got:\n%s\n%s", want, got)
+	}
+
+	for _, format := range []Format{FormatGraphML, FormatGEXF} {
+		buf.Reset()
+		if err := g.Write(&buf, format); err != nil {
+			t.Fatalf("Write %s: %v", format, err)
+		}
+		var doc struct {
+			Nodes []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"graph>node"`
+			GEXFNodes []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"graph>nodes>node"`
+		}
+		if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("Write %s: invalid XML: %v\n%s", format, err, buf.String())
+		}
+		if n := len(doc.Nodes) + len(doc.GEXFNodes); n != 3 {
+			t.Errorf("Write %s: want 3 nodes, got %d", format, n)
+		}
+		if !strings.Contains(buf.String(), "Foo &#34;F&#34; &lt;&amp;&gt;") {
+			t.Errorf("Write %s: want the escaped nickname, got:\n%s", format, buf.String())
+		}
+	}
+}
+
+func TestFormatForFile(t *testing.T) {
+	for file, want := range map[string]Format{"a.gexf": FormatGEXF, "a.GraphML": FormatGraphML, "a.gv": FormatDOT, "a": FormatCSV} {
+		if got, err := FormatForFile(file); err != nil || got != want {
+			t.Errorf("FormatForFile(%q): want %s, got %s, %v", file, want, got, err)
+		}
+	}
+	if _, err := FormatForFile("a.txt"); err == nil {
+		t.Errorf("FormatForFile: want an error for an unknown extension")
+	}
+}
+
+func TestBuildFollows(t *testing.T) {
+	ctx := context.Background()
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Users: []api.UserInfo{
+			{Username: "seed", OUsername: "seed", Nickname: "Seed", Flg: 2},
+			{Username: "a", OUsername: "a"},
+			{Username: "b", OUsername: "b"},
+			{Username: "c", OUsername: "c"},
+			{Username: "d", OUsername: "d"},
+		},
+		Followers: map[string][]string{"seed": {"a", "b"}, "a": {"c"}, "b": {"d"}},
+		Following: map[string][]string{"seed": {"a"}},
+	})
+	defer s.Close()
+	f := model.MakeMemoryFactory(s.MakeClient("me"))
+
+	// Store everything but the followers of b.
+	for _, u := range []string{"seed", "a"} {
+		if _, err := usernames(f.MakeUser(u).Followers(ctx)); err != nil {
+			t.Fatalf("Followers: %v", err)
+		}
+	}
+	if _, err := usernames(f.MakeUser("seed").Following(ctx)); err != nil {
+		t.Fatalf("Following: %v", err)
+	}
+	if err := f.Store().SetUserInfo(ctx, "seed", api.UserInfo{Username: "seed", OUsername: "seed", Nickname: "Seed", Flg: 2}); err != nil {
+		t.Fatalf("SetUserInfo: %v", err)
+	}
+
+	before := len(s.Requests())
+	g, err := BuildFollows(ctx, f, "seed", FollowsDepth(2))
+	if err != nil {
+		t.Fatalf("BuildFollows: %v", err)
+	}
+	if got := len(s.Requests()) - before; got != 0 {
+		t.Errorf("BuildFollows: want nothing read from the API, got %d requests", got)
+	}
+	want := []Edge{
+		{From: "a", To: "seed", Kind: Follow, Weight: 1},
+		{From: "b", To: "seed", Kind: Follow, Weight: 1},
+		{From: "c", To: "a", Kind: Follow, Weight: 1},
+		{From: "seed", To: "a", Kind: Follow, Weight: 1},
+	}
+	if got := g.Edges(); !reflect.DeepEqual(want, got) {
+		t.Errorf("BuildFollows: want != got: %v %v", want, got)
+	}
+	if info, ok := g.UserInfo("seed"); !ok || info.Nickname != "Seed" {
+		t.Errorf("BuildFollows: want the info of seed, got %+v, %v", info, ok)
+	}
+
+	g, err = BuildFollows(ctx, f, "seed", FollowsDepth(2), FollowsFollowersOnly(true), FollowsMaxNodes(2))
+	if err != nil {
+		t.Fatalf("BuildFollows: %v", err)
+	}
+	if want, got := []string{"a", "seed"}, g.Nodes(); !reflect.DeepEqual(want, got) {
+		t.Errorf("BuildFollows with a max: want != got: %v %v", want, got)
+	}
+}
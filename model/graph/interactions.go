@@ -0,0 +1,142 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// postAuthor is who wrote p. Its Username is whose posts it was stored with, which is who shared it if it's
+// someone else's.
+func postAuthor(p model.StoredPost) string {
+	if author := strings.ToLower(p.PostInfo.UID); author != "" {
+		return author
+	}
+	return strings.ToLower(p.Username)
+}
+
+// postSharers are who shared p. Posts stored before sharers were kept only have the last one in Username.
+func postSharers(p model.StoredPost, author string) []string {
+	res := p.Sharers
+	if u := strings.ToLower(p.Username); u != author {
+		res = append(res[:len(res):len(res)], u)
+	}
+	seen := map[string]bool{author: true}
+	var uniq []string
+	for _, s := range res {
+		if !seen[s] {
+			seen[s] = true
+			uniq = append(uniq, s)
+		}
+	}
+	return uniq
+}
+
+func postMentions(p model.StoredPost) []string {
+	if p.Entities.Mentions != nil {
+		return p.Entities.Mentions
+	}
+	return model.ExtractEntities(p.PostInfo.Txt, p.PostInfo.Ttl, p.PostInfo.Dsc).Mentions
+}
+
+func commentMentions(c model.StoredComment) []string {
+	if c.Entities.Mentions != nil {
+		return c.Entities.Mentions
+	}
+	return model.ExtractEntities(c.CommentInfo.Text).Mentions
+}
+
+// filter matches documents created between since and until that were written by, or could be about, users.
+func filter(cdate string, since, until time.Time, userFields []string, users []string) bson.D {
+	res := bson.D{}
+	if r := model.CDateRange(since, until); len(r) > 0 {
+		res = append(res, bson.E{cdate, r})
+	}
+	if len(users) > 0 {
+		var or bson.A
+		for _, f := range userFields {
+			or = append(or, bson.D{{f, bson.D{{"$in", users}}}})
+		}
+		res = append(res, bson.E{"$or", or})
+	}
+	return res
+}
+
+// BuildInteractions builds the graph of replies, mentions and shares in the posts and comments stored in db.
+// With BuildUsernames it's only the interactions to or from those users.
+func BuildInteractions(ctx context.Context, db *model.DB, bOpts ...BuildOption) (*Graph, error) {
+	opts := MakeBuildOptions(bOpts...)
+	var users []string
+	for _, u := range opts.Usernames() {
+		users = append(users, strings.ToLower(u))
+	}
+	g := New()
+
+	// Comments without their parent's author are attributed to the author of the post they're on, if it's
+	// stored.
+	postAuthors := map[string]string{}
+	postFilter := filter("postinfo.cdate", opts.Since(), opts.Until(),
+		[]string{"username", "sharers", "postinfo.uid", "entities.mentions"}, users)
+	if err := forEach(ctx, db.Database().Collection("posts"), postFilter, func(cur *mongo.Cursor) error {
+		var p model.StoredPost
+		if err := cur.Decode(&p); err != nil {
+			return err
+		}
+		author := postAuthor(p)
+		postAuthors[p.PostInfo.ID] = author
+		for _, s := range postSharers(p, author) {
+			g.Add(s, author, Share)
+		}
+		for _, m := range postMentions(p) {
+			g.Add(author, m, Mention)
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Errorf("reading posts: %v", err)
+	}
+
+	commentFilter := filter("commentinfo.cdate", opts.Since(), opts.Until(),
+		[]string{"commentinfo.uid", "commentinfo.puid", "entities.mentions"}, users)
+	if err := forEach(ctx, db.Database().Collection("comments"), commentFilter, func(cur *mongo.Cursor) error {
+		var c model.StoredComment
+		if err := cur.Decode(&c); err != nil {
+			return err
+		}
+		author := c.CommentInfo.UID
+		parent := c.CommentInfo.PUID
+		if parent == "" {
+			parent = postAuthors[c.PostID]
+		}
+		g.Add(author, parent, Reply)
+		for _, m := range commentMentions(c) {
+			g.Add(author, m, Mention)
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Errorf("reading comments: %v", err)
+	}
+
+	if len(users) > 0 {
+		g = g.Touching(users)
+	}
+	return g, nil
+}
+
+func forEach(ctx context.Context, coll *mongo.Collection, filter bson.D, fn func(cur *mongo.Cursor) error) error {
+	cur, err := coll.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		if err := fn(cur); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UserInfoSample is what a user's counts were at Time. A sample is only kept when a count has changed since
+// the one before, so it holds until the next one.
+type UserInfoSample struct {
+	Username         string
+	Time             time.Time
+	Followers        int
+	Following        int
+	TwitterFollowers int
+	TwitterFollowing int
+	Influence        int
+}
+
+func makeUserInfoSample(username string, userInfo api.UserInfo, t time.Time) UserInfoSample {
+	return UserInfoSample{
+		Username:         username,
+		Time:             t.UTC().Truncate(time.Millisecond),
+		Followers:        userInfo.Followers(),
+		Following:        userInfo.Following(),
+		TwitterFollowers: userInfo.TwitterFollowers(),
+		TwitterFollowing: userInfo.TwitterFollowing(),
+		Influence:        userInfo.Infl.Int(),
+	}
+}
+
+func (s UserInfoSample) sameCounts(o UserInfoSample) bool {
+	s.Time, o.Time = time.Time{}, time.Time{}
+	return s == o
+}
+
+// Growth is how much a user's followers changed over a window. From is the sample in effect at the start of
+// the window, or the first one in it, and To the latest.
+type Growth struct {
+	From, To        UserInfoSample
+	Followers       int
+	FollowersPerDay float64
+}
+
+func (d *DB) createUserInfoHistoryIndexes(ctx context.Context) error {
+	if _, err := d.collection("userInfoHistory").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{"username", 1}, {"time", 1}},
+	}); err != nil {
+		return errors.Errorf("creating userInfoHistory index: %v", err)
+	}
+	return nil
+}
+
+// AddUserInfoSample records userInfo's counts at t, when it was fetched, unless they're the same as in the
+// latest sample.
+func (d *DB) AddUserInfoSample(ctx context.Context, username string, userInfo api.UserInfo, t time.Time) error {
+	if !hasUserInfo(userInfo) {
+		return nil
+	}
+	sample := makeUserInfoSample(username, userInfo, t)
+	latest, err := d.latestUserInfoSample(ctx, username, time.Time{})
+	if err != nil {
+		return err
+	}
+	if latest != nil && latest.sameCounts(sample) {
+		return nil
+	}
+	if _, err := d.collection("userInfoHistory").InsertOne(ctx, sample); err != nil {
+		return errors.Errorf("userInfoHistory InsertOne: %v", err)
+	}
+	if d.dbVerboseUserInfo {
+		log.Printf("AddUserInfoSample(%q) -> %+v", username, sample)
+	}
+	return nil
+}
+
+// latestUserInfoSample returns username's last sample taken before before, or at all if it's zero.
+func (d *DB) latestUserInfoSample(ctx context.Context, username string, before time.Time) (*UserInfoSample, error) {
+	filter := bson.D{{"username", username}}
+	if !before.IsZero() {
+		filter = append(filter, bson.E{"time", bson.D{{"$lt", before}}})
+	}
+	findOpts := options.FindOne().SetSort(bson.D{{"time", -1}})
+	var res UserInfoSample
+	if err := d.collection("userInfoHistory").FindOne(ctx, filter, findOpts).Decode(&res); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, errors.Errorf("userInfoHistory FindOne: %v", err)
+	}
+	return &res, nil
+}
+
+// GetUserInfoHistory returns username's samples taken in [since, until), oldest first. A zero since or until
+// leaves that end open.
+func (d *DB) GetUserInfoHistory(ctx context.Context, username string, since, until time.Time) ([]UserInfoSample, error) {
+	filter := bson.D{{"username", username}}
+	timeFilter := bson.D{}
+	if !since.IsZero() {
+		timeFilter = append(timeFilter, bson.E{"$gte", since})
+	}
+	if !until.IsZero() {
+		timeFilter = append(timeFilter, bson.E{"$lt", until})
+	}
+	if len(timeFilter) > 0 {
+		filter = append(filter, bson.E{"time", timeFilter})
+	}
+	findOpts := options.Find().SetSort(bson.D{{"time", 1}})
+	cur, err := d.collection("userInfoHistory").Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, errors.Errorf("userInfoHistory Find: %v", err)
+	}
+	var res []UserInfoSample
+	if err := cur.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetFollowerGrowth returns how username's followers changed over the window up to now, or over all time if
+// it's zero. It's nil if there are no samples.
+func (d *DB) GetFollowerGrowth(ctx context.Context, username string, window time.Duration) (*Growth, error) {
+	now := time.Now()
+	var start time.Time
+	if window > 0 {
+		start = now.Add(-window)
+	}
+	samples, err := d.GetUserInfoHistory(ctx, username, start, now)
+	if err != nil {
+		return nil, err
+	}
+	if !start.IsZero() {
+		// Whatever was sampled last before the window still held at its start.
+		before, err := d.latestUserInfoSample(ctx, username, start)
+		if err != nil {
+			return nil, err
+		}
+		if before != nil {
+			samples = append([]UserInfoSample{*before}, samples...)
+		}
+	}
+	return followerGrowth(samples, start, now), nil
+}
+
+// followerGrowth returns the growth over [start, end] given the samples up to end, oldest first.
+func followerGrowth(samples []UserInfoSample, start, end time.Time) *Growth {
+	if len(samples) == 0 {
+		return nil
+	}
+	from, to := samples[0], samples[len(samples)-1]
+	res := &Growth{From: from, To: to, Followers: to.Followers - from.Followers}
+	if from.Time.After(start) {
+		start = from.Time
+	}
+	if days := end.Sub(start).Hours() / 24; days > 0 {
+		res.FollowersPerDay = float64(res.Followers) / days
+	}
+	return res
+}
@@ -1,13 +1,28 @@
 // DO NOT EDIT MANUALLY: Generated from https://github.com/spudtrooper/genopts
 package model
 
-//go:generate genopts --prefix=MakeDB --outfile=makedboptions.go "port:int" "dbName:string"
+import "time"
+
+//go:generate genopts --prefix=MakeDB --outfile=makedboptions.go "port:int" "dbName:string" "uri:string" "username:string" "password:string" "authSource:string" "tls" "tlsCAFile:string" "tlsInsecure" "replicaSet:string" "connectTimeout:time.Duration" "operationTimeout:time.Duration" "minPoolSize:uint64" "maxPoolSize:uint64" "schemaless"
 
 type MakeDBOption func(*makeDBOptionImpl)
 
 type MakeDBOptions interface {
 	Port() int
 	DbName() string
+	Uri() string
+	Username() string
+	Password() string
+	AuthSource() string
+	Tls() bool
+	TlsCAFile() string
+	TlsInsecure() bool
+	ReplicaSet() string
+	ConnectTimeout() time.Duration
+	OperationTimeout() time.Duration
+	MinPoolSize() uint64
+	MaxPoolSize() uint64
+	Schemaless() bool
 }
 
 func MakeDBPort(port int) MakeDBOption {
@@ -32,13 +47,182 @@ func MakeDBDbNameFlag(dbName *string) MakeDBOption {
 	}
 }
 
+func MakeDBUri(uri string) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.uri = uri
+	}
+}
+func MakeDBUriFlag(uri *string) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.uri = *uri
+	}
+}
+
+func MakeDBUsername(username string) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.username = username
+	}
+}
+func MakeDBUsernameFlag(username *string) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.username = *username
+	}
+}
+
+func MakeDBPassword(password string) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.password = password
+	}
+}
+func MakeDBPasswordFlag(password *string) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.password = *password
+	}
+}
+
+func MakeDBAuthSource(authSource string) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.authSource = authSource
+	}
+}
+func MakeDBAuthSourceFlag(authSource *string) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.authSource = *authSource
+	}
+}
+
+func MakeDBTls(tls bool) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.tls = tls
+	}
+}
+func MakeDBTlsFlag(tls *bool) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.tls = *tls
+	}
+}
+
+func MakeDBTlsCAFile(tlsCAFile string) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.tlsCAFile = tlsCAFile
+	}
+}
+func MakeDBTlsCAFileFlag(tlsCAFile *string) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.tlsCAFile = *tlsCAFile
+	}
+}
+
+func MakeDBTlsInsecure(tlsInsecure bool) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.tlsInsecure = tlsInsecure
+	}
+}
+func MakeDBTlsInsecureFlag(tlsInsecure *bool) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.tlsInsecure = *tlsInsecure
+	}
+}
+
+func MakeDBReplicaSet(replicaSet string) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.replicaSet = replicaSet
+	}
+}
+func MakeDBReplicaSetFlag(replicaSet *string) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.replicaSet = *replicaSet
+	}
+}
+
+func MakeDBConnectTimeout(connectTimeout time.Duration) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.connectTimeout = connectTimeout
+	}
+}
+func MakeDBConnectTimeoutFlag(connectTimeout *time.Duration) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.connectTimeout = *connectTimeout
+	}
+}
+
+func MakeDBOperationTimeout(operationTimeout time.Duration) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.operationTimeout = operationTimeout
+	}
+}
+func MakeDBOperationTimeoutFlag(operationTimeout *time.Duration) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.operationTimeout = *operationTimeout
+	}
+}
+
+func MakeDBMinPoolSize(minPoolSize uint64) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.minPoolSize = minPoolSize
+	}
+}
+func MakeDBMinPoolSizeFlag(minPoolSize *uint64) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.minPoolSize = *minPoolSize
+	}
+}
+
+func MakeDBMaxPoolSize(maxPoolSize uint64) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.maxPoolSize = maxPoolSize
+	}
+}
+func MakeDBMaxPoolSizeFlag(maxPoolSize *uint64) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.maxPoolSize = *maxPoolSize
+	}
+}
+
+func MakeDBSchemaless(schemaless bool) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.schemaless = schemaless
+	}
+}
+func MakeDBSchemalessFlag(schemaless *bool) MakeDBOption {
+	return func(opts *makeDBOptionImpl) {
+		opts.schemaless = *schemaless
+	}
+}
+
 type makeDBOptionImpl struct {
-	port   int
-	dbName string
+	port             int
+	dbName           string
+	uri              string
+	username         string
+	password         string
+	authSource       string
+	tls              bool
+	tlsCAFile        string
+	tlsInsecure      bool
+	replicaSet       string
+	connectTimeout   time.Duration
+	operationTimeout time.Duration
+	minPoolSize      uint64
+	maxPoolSize      uint64
+	schemaless       bool
 }
 
-func (m *makeDBOptionImpl) Port() int      { return m.port }
-func (m *makeDBOptionImpl) DbName() string { return m.dbName }
+func (m *makeDBOptionImpl) Port() int                       { return m.port }
+func (m *makeDBOptionImpl) DbName() string                  { return m.dbName }
+func (m *makeDBOptionImpl) Uri() string                     { return m.uri }
+func (m *makeDBOptionImpl) Username() string                { return m.username }
+func (m *makeDBOptionImpl) Password() string                { return m.password }
+func (m *makeDBOptionImpl) AuthSource() string              { return m.authSource }
+func (m *makeDBOptionImpl) Tls() bool                       { return m.tls }
+func (m *makeDBOptionImpl) TlsCAFile() string               { return m.tlsCAFile }
+func (m *makeDBOptionImpl) TlsInsecure() bool               { return m.tlsInsecure }
+func (m *makeDBOptionImpl) ReplicaSet() string              { return m.replicaSet }
+func (m *makeDBOptionImpl) ConnectTimeout() time.Duration   { return m.connectTimeout }
+func (m *makeDBOptionImpl) OperationTimeout() time.Duration { return m.operationTimeout }
+func (m *makeDBOptionImpl) MinPoolSize() uint64             { return m.minPoolSize }
+func (m *makeDBOptionImpl) MaxPoolSize() uint64             { return m.maxPoolSize }
+func (m *makeDBOptionImpl) Schemaless() bool                { return m.schemaless }
 
 func makeMakeDBOptionImpl(opts ...MakeDBOption) *makeDBOptionImpl {
 	res := &makeDBOptionImpl{}
@@ -0,0 +1,174 @@
+package model
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/api"
+)
+
+// memoryCache is a Cache that keeps values in a map keyed by their path, for tests. Like the disk cache, a
+// key is a directory if other keys are under it.
+type memoryCache struct {
+	mu   sync.Mutex
+	vals map[string][]byte
+}
+
+func MakeMemoryCache() Cache {
+	return &memoryCache{vals: map[string][]byte{}}
+}
+
+func memoryKey(parts ...string) string {
+	return strings.Join(parts, "/")
+}
+
+// under returns the keys below dir, sorted. The caller must hold mu.
+func (c *memoryCache) under(dir string) []string {
+	var res []string
+	for k := range c.vals {
+		if strings.HasPrefix(k, dir+"/") {
+			res = append(res, k)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (c *memoryCache) Has(parts ...string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := memoryKey(parts...)
+	if _, ok := c.vals[key]; ok {
+		return true, nil
+	}
+	return len(c.under(key)) > 0, nil
+}
+
+func (c *memoryCache) Set(parts ...string) error {
+	return c.SetBytes(nil, parts...)
+}
+
+func (c *memoryCache) SetBytes(val []byte, parts ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.vals[memoryKey(parts...)] = append([]byte{}, val...)
+	return nil
+}
+
+func (c *memoryCache) GetBytes(parts ...string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	val, ok := c.vals[memoryKey(parts...)]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte{}, val...), nil
+}
+
+func (c *memoryCache) SetGeneric(val interface{}, parts ...string) error {
+	bytes, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return c.SetBytes(bytes, parts...)
+}
+
+func (c *memoryCache) GetStrings(parts ...string) ([]string, error) {
+	bytes, err := c.GetBytes(parts...)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	if err := json.Unmarshal(bytes, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *memoryCache) GetAllStrings(parts ...string) (ShardedStrings, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	set := map[string]string{}
+	for _, k := range c.under(memoryKey(parts...)) {
+		var arr []string
+		if err := json.Unmarshal(c.vals[k], &arr); err != nil {
+			return nil, err
+		}
+		for _, s := range arr {
+			set[s] = path.Base(k)
+		}
+	}
+	var res ShardedStrings
+	for s, o := range set {
+		res = append(res, ShardedString{Val: s, Dir: o})
+	}
+	return res, nil
+}
+
+func (c *memoryCache) FindKeys(parts ...string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dir := memoryKey(parts...)
+	keys := c.under(dir)
+	if len(keys) == 0 {
+		return nil, errors.Errorf("%s is not a directory", dir)
+	}
+	var res []string
+	for _, k := range keys {
+		res = append(res, path.Base(k))
+	}
+	return res, nil
+}
+
+func (c *memoryCache) FindKeysChannels(parts ...string) (chan string, chan error, error) {
+	c.mu.Lock()
+	dir := memoryKey(parts...)
+	subdirs := map[string]bool{}
+	for _, k := range c.under(dir) {
+		for d := path.Dir(k); d != dir && d != "."; d = path.Dir(d) {
+			subdirs[strings.TrimPrefix(d, dir+"/")] = true
+		}
+	}
+	c.mu.Unlock()
+	if len(subdirs) == 0 {
+		return nil, nil, errors.Errorf("%s is not a directory", dir)
+	}
+
+	keys := make(chan string)
+	errs := make(chan error)
+	go func() {
+		for d := range subdirs {
+			keys <- d
+		}
+		close(keys)
+		close(errs)
+	}()
+	return keys, errs, nil
+}
+
+func (c *memoryCache) Delete(parts ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := memoryKey(parts...)
+	for _, k := range c.under(key) {
+		delete(c.vals, k)
+	}
+	delete(c.vals, key)
+	return nil
+}
+
+// MakeMemoryStore returns a Store that keeps everything in memory, for tests.
+func MakeMemoryStore() Store {
+	return MakeCacheStore(MakeMemoryCache())
+}
+
+// MakeMemoryFactory returns a Factory that reads from client and keeps everything in memory, so that tests
+// needn't run MongoDB or write to --cache_dir.
+func MakeMemoryFactory(client *api.Core) Factory {
+	cache := MakeMemoryCache()
+	return makeFactory(cache, client, MakeCacheStore(cache), nil, factoryOptions{})
+}
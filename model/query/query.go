@@ -0,0 +1,256 @@
+// Package query finds stored posts by what they say, who posted them, when, and how much engagement they got.
+package query
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Sort string
+
+const (
+	SortNewest   Sort = "newest"
+	SortLikes    Sort = "likes"
+	SortShares   Sort = "shares"
+	SortComments Sort = "comments"
+)
+
+func ParseSort(s string) (Sort, error) {
+	switch sort := Sort(s); sort {
+	case "":
+		return SortNewest, nil
+	case SortNewest, SortLikes, SortShares, SortComments:
+		return sort, nil
+	}
+	return "", errors.Errorf("invalid sort %q: want newest, likes, shares or comments", s)
+}
+
+// Posts is a query of stored posts. Every criterion that's set has to match, and zero values match everything.
+type Posts struct {
+	// Text is matched case-insensitively against the text, title and description.
+	Text string
+	// Authors are who wrote the posts, whoever's posts they were stored with.
+	Authors []string
+	// Since and Until bound when posts were created, including Since and excluding Until.
+	Since, Until time.Time
+	// Hashtags all have to be in the text, with or without their leading #.
+	Hashtags    []string
+	Lang        string
+	MinLikes    int
+	MinShares   int
+	MinComments int
+	HasImages   bool
+	Sort        Sort
+	Limit       int
+}
+
+func containsRegex(s string) primitive.Regex {
+	return primitive.Regex{Pattern: regexp.QuoteMeta(s), Options: "i"}
+}
+
+// Filter is the filter of the posts collection for q.
+func (q Posts) Filter() bson.D {
+	res := bson.D{}
+	if q.Text != "" {
+		re := containsRegex(q.Text)
+		res = append(res, bson.E{"$or", bson.A{
+			bson.D{{"postinfo.txt", re}},
+			bson.D{{"postinfo.ttl", re}},
+			bson.D{{"postinfo.dsc", re}},
+		}})
+	}
+	if len(q.Authors) > 0 {
+		var authors []string
+		for _, a := range q.Authors {
+			authors = append(authors, strings.ToLower(a))
+		}
+		res = append(res, bson.E{"postinfo.uid", bson.D{{"$in", authors}}})
+	}
+	if cdate := model.CDateRange(q.Since, q.Until); len(cdate) > 0 {
+		res = append(res, bson.E{"postinfo.cdate", cdate})
+	}
+	if len(q.Hashtags) > 0 {
+		var tags bson.A
+		for _, h := range q.Hashtags {
+			h = strings.TrimPrefix(h, "#")
+			re := primitive.Regex{Pattern: "#" + regexp.QuoteMeta(h) + `\b`, Options: "i"}
+			tags = append(tags, bson.D{{"postinfo.txt", re}})
+		}
+		res = append(res, bson.E{"$and", tags})
+	}
+	if q.Lang != "" {
+		res = append(res, bson.E{"postinfo.txtlang", q.Lang})
+	}
+	if q.MinLikes > 0 {
+		res = append(res, bson.E{"postinfo.lkbpst", bson.D{{"$gte", q.MinLikes}}})
+	}
+	if q.MinShares > 0 {
+		res = append(res, bson.E{"postinfo.shbpst", bson.D{{"$gte", q.MinShares}}})
+	}
+	if q.MinComments > 0 {
+		res = append(res, bson.E{"postinfo.cm", bson.D{{"$gte", q.MinComments}}})
+	}
+	if q.HasImages {
+		res = append(res, bson.E{"postinfo.imgs.0", bson.D{{"$exists", true}}})
+	}
+	return res
+}
+
+func (q Posts) sort() bson.D {
+	switch q.Sort {
+	case SortLikes:
+		return bson.D{{"postinfo.lkbpst", -1}, {"postinfo.cdate", -1}}
+	case SortShares:
+		return bson.D{{"postinfo.shbpst", -1}, {"postinfo.cdate", -1}}
+	case SortComments:
+		return bson.D{{"postinfo.cm", -1}, {"postinfo.cdate", -1}}
+	}
+	return bson.D{{"postinfo.cdate", -1}}
+}
+
+// Post is a stored post along with who posted it.
+type Post = model.StoredPost
+
+// Find returns the posts in db matching q.
+func Find(ctx context.Context, db *model.DB, q Posts) ([]Post, error) {
+	findOpts := options.Find().SetSort(q.sort())
+	if q.Limit > 0 {
+		findOpts.SetLimit(int64(q.Limit))
+	}
+	cur, err := db.Database().Collection("posts").Find(ctx, q.Filter(), findOpts)
+	if err != nil {
+		return nil, errors.Errorf("posts Find: %v", err)
+	}
+	var res []Post
+	if err := cur.All(ctx, &res); err != nil {
+		return nil, errors.Errorf("decoding posts: %v", err)
+	}
+	return res, nil
+}
+
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatJSON  Format = "json"
+	FormatCSV   Format = "csv"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case "":
+		return FormatTable, nil
+	case FormatTable, FormatJSON, FormatCSV:
+		return f, nil
+	}
+	return "", errors.Errorf("invalid format %q: want table, json or csv", s)
+}
+
+// row is how a post is written in every format.
+type row struct {
+	ID       string    `json:"id"`
+	Author   string    `json:"author"`
+	Created  time.Time `json:"created"`
+	Likes    int       `json:"likes"`
+	Shares   int       `json:"shares"`
+	Comments int       `json:"comments"`
+	Lang     string    `json:"lang"`
+	Images   int       `json:"images"`
+	URI      string    `json:"uri"`
+	Text     string    `json:"text"`
+}
+
+func makeRow(p Post) row {
+	created, _ := p.PostInfo.CDate.Time()
+	// Posts stored before their authors were kept only have who they were stored with.
+	author := p.PostInfo.UID
+	if author == "" {
+		author = p.Username
+	}
+	return row{
+		ID:       p.PostInfo.ID,
+		Author:   author,
+		Created:  created.UTC(),
+		Likes:    p.PostInfo.Lkbpst,
+		Shares:   p.PostInfo.Shbpst,
+		Comments: p.PostInfo.Cm,
+		Lang:     p.PostInfo.TxtLang,
+		Images:   len(p.PostInfo.IMGs),
+		URI:      p.PostInfo.URI(),
+		Text:     p.PostInfo.Txt,
+	}
+}
+
+func (r row) strings() []string {
+	return []string{
+		r.ID,
+		r.Author,
+		r.Created.Format(time.RFC3339),
+		strconv.Itoa(r.Likes),
+		strconv.Itoa(r.Shares),
+		strconv.Itoa(r.Comments),
+		r.Lang,
+		strconv.Itoa(r.Images),
+		r.URI,
+		r.Text,
+	}
+}
+
+var header = []string{"id", "author", "created", "likes", "shares", "comments", "lang", "images", "uri", "text"}
+
+// maxTableText is how much of a post's text fits in a table row.
+const maxTableText = 60
+
+// Write writes posts to w as an aligned table, a JSON array, or CSV with a header.
+func Write(w io.Writer, format Format, posts []Post) error {
+	rows := []row{}
+	for _, p := range posts {
+		rows = append(rows, makeRow(p))
+	}
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		for _, r := range rows {
+			if err := cw.Write(r.strings()); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatTable, "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		// The URI is left out since it's just the ID with a prefix.
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(append(header[:8:8], "text"), "\t")))
+		for _, r := range rows {
+			text := strings.Join(strings.Fields(r.Text), " ")
+			if rs := []rune(text); len(rs) > maxTableText {
+				text = string(rs[:maxTableText-3]) + "..."
+			}
+			s := r.strings()
+			fmt.Fprintln(tw, strings.Join(append(s[:8:8], text), "\t"))
+		}
+		return tw.Flush()
+	}
+	return errors.Errorf("invalid format %q", format)
+}
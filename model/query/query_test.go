@@ -0,0 +1,105 @@
+package query
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spudtrooper/gettr/api"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFilter(t *testing.T) {
+	since := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		q    Posts
+		want bson.D
+	}{
+		{
+			name: "empty",
+			want: bson.D{},
+		},
+		{
+			name: "text",
+			q:    Posts{Text: "a.b"},
+			want: bson.D{{"$or", bson.A{
+				bson.D{{"postinfo.txt", primitive.Regex{Pattern: `a\.b`, Options: "i"}}},
+				bson.D{{"postinfo.ttl", primitive.Regex{Pattern: `a\.b`, Options: "i"}}},
+				bson.D{{"postinfo.dsc", primitive.Regex{Pattern: `a\.b`, Options: "i"}}},
+			}}},
+		},
+		{
+			name: "everything else",
+			q: Posts{
+				Authors:     []string{"Foo", "bar"},
+				Since:       since,
+				Until:       until,
+				Hashtags:    []string{"#maga", "fjb"},
+				Lang:        "en",
+				MinLikes:    1,
+				MinShares:   2,
+				MinComments: 3,
+				HasImages:   true,
+			},
+			want: bson.D{
+				{"postinfo.uid", bson.D{{"$in", []string{"foo", "bar"}}}},
+				{"postinfo.cdate", bson.D{{"$gte", int64(1646092800000)}, {"$lt", int64(1648771200000)}}},
+				{"$and", bson.A{
+					bson.D{{"postinfo.txt", primitive.Regex{Pattern: `#maga\b`, Options: "i"}}},
+					bson.D{{"postinfo.txt", primitive.Regex{Pattern: `#fjb\b`, Options: "i"}}},
+				}},
+				{"postinfo.txtlang", "en"},
+				{"postinfo.lkbpst", bson.D{{"$gte", 1}}},
+				{"postinfo.shbpst", bson.D{{"$gte", 2}}},
+				{"postinfo.cm", bson.D{{"$gte", 3}}},
+				{"postinfo.imgs.0", bson.D{{"$exists", true}}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.q.Filter(); !reflect.DeepEqual(test.want, got) {
+				t.Errorf("Filter: want %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	// Stored with bar, who shared it.
+	posts := []Post{{
+		Username: "bar",
+		PostInfo: api.PostInfo{
+			ID:     "p1",
+			UID:    "foo",
+			CDate:  1646092800000,
+			Txt:    "hello,\nworld",
+			Lkbpst: 5,
+			IMGs:   []string{"a.jpg"},
+		},
+	}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, posts); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	want := "id,author,created,likes,shares,comments,lang,images,uri,text\n" +
+		"p1,foo,2022-03-01T00:00:00Z,5,0,0,,1," + api.PostInfo{ID: "p1"}.URI() + ",\"hello,\nworld\"\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Write csv: want %q, got %q", want, got)
+	}
+
+	buf.Reset()
+	if err := Write(&buf, FormatTable, posts); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[1], "hello, world") {
+		t.Errorf("Write table: want a header and one row ending in the flattened text, got %q", buf.String())
+	}
+}
@@ -0,0 +1,307 @@
+// Package queue is a work queue kept in MongoDB, with a document per item so that any number of workers, in
+// any number of processes, can lease items from it. A leased item is hidden from other workers until its lease
+// expires, so the items of a worker that dies are handed out again. Items that fail too many times are
+// dead-lettered instead of being retried forever.
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/goutil/or"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type State string
+
+const (
+	Pending State = "pending"
+	Leased  State = "leased"
+	Done    State = "done"
+	Dead    State = "dead"
+)
+
+// Item is a key in a queue. While it's Pending or Leased it can't be leased before VisibleAt.
+type Item struct {
+	Queue     string
+	Key       string
+	State     State
+	Attempts  int
+	VisibleAt time.Time
+	LeaseID   string
+	LastError string
+	Updated   time.Time
+}
+
+type Stats struct {
+	Pending, Leased, Done, Dead int64
+}
+
+// Remaining is how many items haven't been finished yet.
+func (s Stats) Remaining() int64 { return s.Pending + s.Leased }
+
+func (s Stats) Total() int64 { return s.Pending + s.Leased + s.Done + s.Dead }
+
+// ErrLeaseLost is returned when finishing or extending an item whose lease expired and was given to another
+// worker.
+var ErrLeaseLost = errors.New("lease lost")
+
+// Queue is the queue called name in a collection shared by every queue.
+type Queue struct {
+	coll              *mongo.Collection
+	name              string
+	visibilityTimeout time.Duration
+	maxAttempts       int
+	retryDelay        time.Duration
+}
+
+const collection = "queueItems"
+
+// Make returns the queue name in db, creating its indexes if needed.
+func Make(ctx context.Context, db *mongo.Database, name string, mOpts ...MakeOption) (*Queue, error) {
+	opts := MakeMakeOptions(mOpts...)
+	q := &Queue{
+		coll:              db.Collection(collection),
+		name:              name,
+		visibilityTimeout: opts.VisibilityTimeout(),
+		maxAttempts:       or.Int(opts.MaxAttempts(), 5),
+		retryDelay:        opts.RetryDelay(),
+	}
+	if q.visibilityTimeout == 0 {
+		q.visibilityTimeout = 5 * time.Minute
+	}
+	if _, err := q.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{"queue", 1}, {"key", 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{"queue", 1}, {"state", 1}, {"visibleat", 1}}},
+	}); err != nil {
+		return nil, errors.Errorf("creating %s indexes: %v", collection, err)
+	}
+	return q, nil
+}
+
+func (q *Queue) Name() string { return q.name }
+
+// VisibilityTimeout is how long a lease lasts unless it's extended.
+func (q *Queue) VisibilityTimeout() time.Duration { return q.visibilityTimeout }
+
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// Add adds keys as pending items, leaving any already in the queue as they are, and returns how many were new.
+func (q *Queue) Add(ctx context.Context, keys ...string) (int64, error) {
+	const batchSize = 1000
+	var added int64
+	t := now()
+	for len(keys) > 0 {
+		n := batchSize
+		if n > len(keys) {
+			n = len(keys)
+		}
+		var models []mongo.WriteModel
+		for _, k := range keys[:n] {
+			item := Item{Queue: q.name, Key: k, State: Pending, VisibleAt: t, Updated: t}
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.D{{"queue", q.name}, {"key", k}}).
+				SetUpdate(bson.D{{"$setOnInsert", item}}).
+				SetUpsert(true))
+		}
+		res, err := q.coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return added, errors.Errorf("%s BulkWrite: %v", collection, err)
+		}
+		added += res.UpsertedCount
+		keys = keys[n:]
+	}
+	return added, nil
+}
+
+// Lease leases the next visible item, or returns nil if none are.
+func (q *Queue) Lease(ctx context.Context) (*Item, error) {
+	item, err := q.lease(ctx)
+	if err != nil || item != nil {
+		return item, err
+	}
+	// Expired leases of items out of attempts are never leased again, so they're dead-lettered here, when
+	// there's nothing else to do.
+	if _, err := q.reap(ctx); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (q *Queue) lease(ctx context.Context) (*Item, error) {
+	t := now()
+	filter := bson.D{
+		{"queue", q.name},
+		{"state", bson.D{{"$in", bson.A{Pending, Leased}}}},
+		{"visibleat", bson.D{{"$lte", t}}},
+		{"attempts", bson.D{{"$lt", q.maxAttempts}}},
+	}
+	update := bson.D{
+		{"$set", bson.D{
+			{"state", Leased},
+			{"visibleat", t.Add(q.visibilityTimeout)},
+			{"leaseid", primitive.NewObjectID().Hex()},
+			{"updated", t},
+		}},
+		{"$inc", bson.D{{"attempts", 1}}},
+	}
+	findOpts := options.FindOneAndUpdate().
+		SetSort(bson.D{{"visibleat", 1}}).
+		SetReturnDocument(options.After)
+	var item Item
+	if err := q.coll.FindOneAndUpdate(ctx, filter, update, findOpts).Decode(&item); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, errors.Errorf("%s FindOneAndUpdate: %v", collection, err)
+	}
+	return &item, nil
+}
+
+func (q *Queue) reap(ctx context.Context) (int64, error) {
+	t := now()
+	filter := bson.D{
+		{"queue", q.name},
+		{"state", Leased},
+		{"visibleat", bson.D{{"$lte", t}}},
+		{"attempts", bson.D{{"$gte", q.maxAttempts}}},
+	}
+	update := bson.D{{"$set", bson.D{
+		{"state", Dead},
+		{"lasterror", "lease expired"},
+		{"updated", t},
+	}}}
+	res, err := q.coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, errors.Errorf("%s UpdateMany: %v", collection, err)
+	}
+	return res.ModifiedCount, nil
+}
+
+// updateLeased applies update to item if it's still leased by this lease.
+func (q *Queue) updateLeased(ctx context.Context, item *Item, update bson.D) error {
+	filter := bson.D{{"queue", q.name}, {"key", item.Key}, {"state", Leased}, {"leaseid", item.LeaseID}}
+	res, err := q.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.Errorf("%s UpdateOne: %v", collection, err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Extend extends the lease of item by the visibility timeout.
+func (q *Queue) Extend(ctx context.Context, item *Item) error {
+	t := now()
+	return q.updateLeased(ctx, item, bson.D{{"$set", bson.D{
+		{"visibleat", t.Add(q.visibilityTimeout)},
+		{"updated", t},
+	}}})
+}
+
+// Complete marks item done.
+func (q *Queue) Complete(ctx context.Context, item *Item) error {
+	return q.updateLeased(ctx, item, bson.D{{"$set", bson.D{
+		{"state", Done},
+		{"leaseid", ""},
+		{"lasterror", ""},
+		{"updated", now()},
+	}}})
+}
+
+// Fail returns item to the queue, to be leased again after the retry delay, or dead-letters it if it's out of
+// attempts.
+func (q *Queue) Fail(ctx context.Context, item *Item, cause error) error {
+	t := now()
+	state := Pending
+	if item.Attempts >= q.maxAttempts {
+		state = Dead
+	}
+	return q.updateLeased(ctx, item, bson.D{{"$set", bson.D{
+		{"state", state},
+		{"visibleat", t.Add(q.retryDelay)},
+		{"leaseid", ""},
+		{"lasterror", cause.Error()},
+		{"updated", t},
+	}}})
+}
+
+// Stats counts the items in each state.
+func (q *Queue) Stats(ctx context.Context) (Stats, error) {
+	pipeline := mongo.Pipeline{
+		{{"$match", bson.D{{"queue", q.name}}}},
+		{{"$group", bson.D{{"_id", "$state"}, {"count", bson.D{{"$sum", 1}}}}}},
+	}
+	cur, err := q.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return Stats{}, errors.Errorf("%s Aggregate: %v", collection, err)
+	}
+	var groups []struct {
+		State State `bson:"_id"`
+		Count int64
+	}
+	if err := cur.All(ctx, &groups); err != nil {
+		return Stats{}, err
+	}
+	var res Stats
+	for _, g := range groups {
+		switch g.State {
+		case Pending:
+			res.Pending = g.Count
+		case Leased:
+			res.Leased = g.Count
+		case Done:
+			res.Done = g.Count
+		case Dead:
+			res.Dead = g.Count
+		}
+	}
+	return res, nil
+}
+
+// DeadItems returns the dead-lettered items.
+func (q *Queue) DeadItems(ctx context.Context) ([]Item, error) {
+	cur, err := q.coll.Find(ctx, bson.D{{"queue", q.name}, {"state", Dead}}, options.Find().SetSort(bson.D{{"key", 1}}))
+	if err != nil {
+		return nil, errors.Errorf("%s Find: %v", collection, err)
+	}
+	var res []Item
+	if err := cur.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// RetryDead returns the dead-lettered items to the queue with their attempts reset.
+func (q *Queue) RetryDead(ctx context.Context) (int64, error) {
+	t := now()
+	update := bson.D{{"$set", bson.D{
+		{"state", Pending},
+		{"attempts", 0},
+		{"visibleat", t},
+		{"updated", t},
+	}}}
+	res, err := q.coll.UpdateMany(ctx, bson.D{{"queue", q.name}, {"state", Dead}}, update)
+	if err != nil {
+		return 0, errors.Errorf("%s UpdateMany: %v", collection, err)
+	}
+	return res.ModifiedCount, nil
+}
+
+// Reset removes every item from the queue.
+func (q *Queue) Reset(ctx context.Context) error {
+	if _, err := q.coll.DeleteMany(ctx, bson.D{{"queue", q.name}}); err != nil {
+		return errors.Errorf("%s DeleteMany: %v", collection, err)
+	}
+	return nil
+}
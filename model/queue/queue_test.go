@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/model"
+)
+
+func makeTestQueue(ctx context.Context, t *testing.T, mOpts ...MakeOption) *Queue {
+	db, err := model.MakeDB(ctx, model.MakeDBDbName("gettrtest"))
+	if err != nil {
+		t.Fatalf("MakeDB: %v", err)
+	}
+	t.Cleanup(func() { db.Disconnect(ctx) })
+	q, err := Make(ctx, db.Database(), "test/"+t.Name(), mOpts...)
+	if err != nil {
+		t.Fatalf("Make: %v", err)
+	}
+	if err := q.Reset(ctx); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	return q
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	q := makeTestQueue(ctx, t, MakeMaxAttempts(2))
+
+	if added, err := q.Add(ctx, "a", "b"); err != nil {
+		t.Fatalf("Add: %v", err)
+	} else if added != 2 {
+		t.Errorf("Add: want 2 added, got %d", added)
+	}
+	if added, err := q.Add(ctx, "b", "c"); err != nil {
+		t.Fatalf("Add: %v", err)
+	} else if added != 1 {
+		t.Errorf("Add: want only c added, got %d", added)
+	}
+
+	leased := map[string]*Item{}
+	for i := 0; i < 3; i++ {
+		item, err := q.Lease(ctx)
+		if err != nil || item == nil {
+			t.Fatalf("Lease: want an item, got %v, %v", item, err)
+		}
+		leased[item.Key] = item
+	}
+	if item, err := q.Lease(ctx); err != nil || item != nil {
+		t.Fatalf("Lease: want nothing while all are leased, got %v, %v", item, err)
+	}
+
+	if err := q.Complete(ctx, leased["a"]); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if err := q.Complete(ctx, leased["a"]); err != ErrLeaseLost {
+		t.Errorf("Complete: want ErrLeaseLost when done twice, got %v", err)
+	}
+	// b fails once and is retried, and c fails until it's dead.
+	if err := q.Fail(ctx, leased["b"], errors.New("oops")); err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+	if err := q.Fail(ctx, leased["c"], errors.New("oops")); err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		item, err := q.Lease(ctx)
+		if err != nil || item == nil {
+			t.Fatalf("Lease: want a retry, got %v, %v", item, err)
+		}
+		if item.Key == "b" {
+			err = q.Complete(ctx, item)
+		} else {
+			err = q.Fail(ctx, item, errors.New("oops again"))
+		}
+		if err != nil {
+			t.Fatalf("finishing %s: %v", item.Key, err)
+		}
+	}
+
+	if stats, err := q.Stats(ctx); err != nil {
+		t.Fatalf("Stats: %v", err)
+	} else if want := (Stats{Done: 2, Dead: 1}); stats != want {
+		t.Errorf("Stats: want %+v, got %+v", want, stats)
+	}
+	if dead, err := q.DeadItems(ctx); err != nil {
+		t.Fatalf("DeadItems: %v", err)
+	} else if len(dead) != 1 || dead[0].Key != "c" || dead[0].LastError != "oops again" {
+		t.Errorf("DeadItems: want c, got %+v", dead)
+	}
+	if n, err := q.RetryDead(ctx); err != nil || n != 1 {
+		t.Errorf("RetryDead: want 1, got %d, %v", n, err)
+	}
+}
+
+func TestQueueLeaseExpires(t *testing.T) {
+	ctx := context.Background()
+	q := makeTestQueue(ctx, t, MakeVisibilityTimeout(100*time.Millisecond))
+
+	if _, err := q.Add(ctx, "a"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	first, err := q.Lease(ctx)
+	if err != nil || first == nil {
+		t.Fatalf("Lease: want an item, got %v, %v", first, err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	second, err := q.Lease(ctx)
+	if err != nil || second == nil {
+		t.Fatalf("Lease: want the expired item again, got %v, %v", second, err)
+	}
+	if err := q.Complete(ctx, first); err != ErrLeaseLost {
+		t.Errorf("Complete: want ErrLeaseLost for the expired lease, got %v", err)
+	}
+	if err := q.Complete(ctx, second); err != nil {
+		t.Errorf("Complete: %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package model
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SnapshotKind string
+
+const (
+	SnapshotFollowers SnapshotKind = "followers"
+	SnapshotFollowing SnapshotKind = "following"
+)
+
+// Snapshot is a copy, taken at Created, of the followers or following of Username. Complete is false when
+// they hadn't all been read yet.
+type Snapshot struct {
+	ID       string
+	Username string
+	Kind     SnapshotKind
+	Created  time.Time
+	Count    int
+	Complete bool
+}
+
+type SnapshotDiff struct {
+	From, To Snapshot
+	Gained   []string
+	Lost     []string
+}
+
+type storedSnapshotMember struct {
+	SnapshotID string
+	Member     string
+}
+
+const snapshotMembersBatchSize = 1000
+
+func (d *DB) createSnapshotIndexes(ctx context.Context) error {
+	if _, err := d.collection("snapshots").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{"username", 1}, {"kind", 1}, {"created", 1}},
+	}); err != nil {
+		return errors.Errorf("creating snapshots index: %v", err)
+	}
+	if _, err := d.collection("snapshots").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"id", 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return errors.Errorf("creating snapshots id index: %v", err)
+	}
+	if _, err := d.collection("snapshotMembers").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"snapshotid", 1}, {"member", 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return errors.Errorf("creating snapshotMembers index: %v", err)
+	}
+	return nil
+}
+
+// TakeSnapshot copies the stored followers or following of username into a new snapshot.
+func (d *DB) TakeSnapshot(ctx context.Context, username string, kind SnapshotKind) (*Snapshot, error) {
+	var shards func(context.Context, string) ([]api.OffsetStrings, error)
+	var done func(context.Context, string) (bool, error)
+	switch kind {
+	case SnapshotFollowers:
+		shards, done = d.GetFollowersShards, d.GetUserFollowersDone
+	case SnapshotFollowing:
+		shards, done = d.GetFollowingShards, d.GetUserFollowingDone
+	default:
+		return nil, errors.Errorf("invalid snapshot kind %q", kind)
+	}
+
+	ss, err := shards(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	complete, err := done(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	members := map[string]bool{}
+	for _, s := range ss {
+		for _, m := range s.Strings {
+			members[m] = true
+		}
+	}
+
+	snapshot := Snapshot{
+		ID:       primitive.NewObjectID().Hex(),
+		Username: username,
+		Kind:     kind,
+		Created:  time.Now().UTC().Truncate(time.Millisecond),
+		Count:    len(members),
+		Complete: complete,
+	}
+	var batch []interface{}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := d.collection("snapshotMembers").InsertMany(ctx, batch, options.InsertMany().SetOrdered(false)); err != nil {
+			return errors.Errorf("snapshotMembers InsertMany: %v", err)
+		}
+		batch = nil
+		return nil
+	}
+	for m := range members {
+		batch = append(batch, storedSnapshotMember{SnapshotID: snapshot.ID, Member: m})
+		if len(batch) == snapshotMembersBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	// The snapshot goes in last so that it's never listed without its members.
+	if _, err := d.collection("snapshots").InsertOne(ctx, snapshot); err != nil {
+		return nil, errors.Errorf("snapshots InsertOne: %v", err)
+	}
+	if !complete {
+		log.Printf("snapshot %s of %s's %s is incomplete because they haven't all been read", snapshot.ID, username, kind)
+	}
+	return &snapshot, nil
+}
+
+func (d *DB) GetSnapshot(ctx context.Context, id string) (*Snapshot, error) {
+	var res Snapshot
+	if err := d.collection("snapshots").FindOne(ctx, bson.D{{"id", id}}).Decode(&res); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.Errorf("no snapshot %q", id)
+		}
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetSnapshots returns the snapshots of username's followers or following, oldest first.
+func (d *DB) GetSnapshots(ctx context.Context, username string, kind SnapshotKind) ([]Snapshot, error) {
+	filter := bson.D{{"username", username}, {"kind", kind}}
+	findOpts := options.Find().SetSort(bson.D{{"created", 1}})
+	cur, err := d.collection("snapshots").Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, errors.Errorf("snapshots Find: %v", err)
+	}
+	var res []Snapshot
+	if err := cur.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (d *DB) getSnapshotMembers(ctx context.Context, id string) ([]string, error) {
+	cur, err := d.collection("snapshotMembers").Find(ctx, bson.D{{"snapshotid", id}})
+	if err != nil {
+		return nil, errors.Errorf("snapshotMembers Find: %v", err)
+	}
+	var members []storedSnapshotMember
+	if err := cur.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, m := range members {
+		res = append(res, m.Member)
+	}
+	return res, nil
+}
+
+// DiffSnapshots returns who is in the snapshot toID but not fromID, and vice versa. Both must be of the same
+// user's followers or following.
+func (d *DB) DiffSnapshots(ctx context.Context, fromID, toID string) (*SnapshotDiff, error) {
+	from, err := d.GetSnapshot(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := d.GetSnapshot(ctx, toID)
+	if err != nil {
+		return nil, err
+	}
+	if from.Username != to.Username || from.Kind != to.Kind {
+		return nil, errors.Errorf("can't diff %s's %s with %s's %s", from.Username, from.Kind, to.Username, to.Kind)
+	}
+	fromMembers, err := d.getSnapshotMembers(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+	toMembers, err := d.getSnapshotMembers(ctx, toID)
+	if err != nil {
+		return nil, err
+	}
+	gained, lost := diffMembers(fromMembers, toMembers)
+	return &SnapshotDiff{From: *from, To: *to, Gained: gained, Lost: lost}, nil
+}
+
+// DeleteSnapshot deletes the snapshot id and its members.
+func (d *DB) DeleteSnapshot(ctx context.Context, id string) error {
+	if _, err := d.collection("snapshots").DeleteOne(ctx, bson.D{{"id", id}}); err != nil {
+		return errors.Errorf("snapshots DeleteOne: %v", err)
+	}
+	if _, err := d.collection("snapshotMembers").DeleteMany(ctx, bson.D{{"snapshotid", id}}); err != nil {
+		return errors.Errorf("snapshotMembers DeleteMany: %v", err)
+	}
+	return nil
+}
+
+// diffMembers returns, sorted, those in to but not from and those in from but not to.
+func diffMembers(from, to []string) (gained, lost []string) {
+	inFrom, inTo := map[string]bool{}, map[string]bool{}
+	for _, m := range from {
+		inFrom[m] = true
+	}
+	for _, m := range to {
+		inTo[m] = true
+	}
+	for m := range inTo {
+		if !inFrom[m] {
+			gained = append(gained, m)
+		}
+	}
+	for m := range inFrom {
+		if !inTo[m] {
+			lost = append(lost, m)
+		}
+	}
+	sort.Strings(gained)
+	sort.Strings(lost)
+	return
+}
@@ -0,0 +1,385 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/log"
+	"github.com/spudtrooper/goutil/flags"
+)
+
+var (
+	sqliteFile    = flag.String("sqlite_file", "../gettrdata/gettr.db", "SQLite database used with --store=sqlite")
+	sqliteVerbose = flags.Bool("sqlite_verbose", "verbose logging for the SQLite store")
+)
+
+// sqliteMigrations bring a database up to date when applied in order. The number applied so far is kept in
+// the database's user_version, so only ever append to this.
+var sqliteMigrations = []string{
+	`CREATE TABLE users (
+		username       TEXT PRIMARY KEY,
+		user_info      TEXT,
+		skip           INTEGER NOT NULL DEFAULT 0,
+		followers_done INTEGER NOT NULL DEFAULT 0,
+		following_done INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE followers (
+		username     TEXT NOT NULL,
+		shard_offset INTEGER NOT NULL,
+		usernames    TEXT NOT NULL,
+		PRIMARY KEY (username, shard_offset)
+	);
+	CREATE TABLE following (
+		username     TEXT NOT NULL,
+		shard_offset INTEGER NOT NULL,
+		usernames    TEXT NOT NULL,
+		PRIMARY KEY (username, shard_offset)
+	);
+	CREATE TABLE posts (
+		id        TEXT PRIMARY KEY,
+		username  TEXT NOT NULL,
+		cdate     INTEGER NOT NULL,
+		post_info TEXT NOT NULL
+	);
+	CREATE INDEX posts_username ON posts (username);
+	CREATE INDEX posts_cdate ON posts (cdate);`,
+}
+
+// SQLiteDB is a Store in a SQLite file, for when running MongoDB isn't an option.
+type SQLiteDB struct {
+	db      *sql.DB
+	verbose bool
+}
+
+// MakeSQLiteDB opens or creates the database in file and migrates it to the latest schema. Use ":memory:" for
+// a database that's discarded on Close.
+func MakeSQLiteDB(ctx context.Context, file string) (*SQLiteDB, error) {
+	dsn := ":memory:"
+	if file != ":memory:" {
+		if err := os.MkdirAll(path.Dir(file), 0755); err != nil {
+			return nil, err
+		}
+		dsn = fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL", file)
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, and an in-memory database exists only for its connection.
+	db.SetMaxOpenConns(1)
+	if err := migrateSQLite(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	log.Printf("opened %s", file)
+	return &SQLiteDB{db: db, verbose: *sqliteVerbose}, nil
+}
+
+func MakeSQLiteDBFromFlags(ctx context.Context) (*SQLiteDB, error) {
+	if *sqliteFile == "" {
+		return nil, errors.Errorf("must set --sqlite_file")
+	}
+	return MakeSQLiteDB(ctx, *sqliteFile)
+}
+
+func migrateSQLite(ctx context.Context, db *sql.DB) error {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return errors.Errorf("reading schema version: %v", err)
+	}
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return errors.Errorf("migration %d: %v", i+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return errors.Errorf("migration %d: %v", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Printf("migrated SQLite schema to version %d", i+1)
+	}
+	return nil
+}
+
+func (s *SQLiteDB) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteDB) GetUserInfo(ctx context.Context, username string) (*api.UserInfo, error) {
+	var b sql.NullString
+	err := s.db.QueryRowContext(ctx, "SELECT user_info FROM users WHERE username = ?", username).Scan(&b)
+	if err == sql.ErrNoRows || (err == nil && !b.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var res api.UserInfo
+	if err := json.Unmarshal([]byte(b.String), &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (s *SQLiteDB) SetUserInfo(ctx context.Context, username string, userInfo api.UserInfo) error {
+	b, err := json.Marshal(userInfo)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO users (username, user_info) VALUES (?, ?)
+		ON CONFLICT (username) DO UPDATE SET user_info = excluded.user_info`, username, string(b))
+	if s.verbose {
+		log.Printf("SetUserInfo(%q) -> %v", username, err)
+	}
+	return err
+}
+
+// getUserFlag and setUserFlag read and write one of the boolean columns of users; column is never user input.
+func (s *SQLiteDB) getUserFlag(ctx context.Context, username, column string) (bool, error) {
+	var res bool
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf("SELECT %s FROM users WHERE username = ?", column), username).Scan(&res)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return res, err
+}
+
+func (s *SQLiteDB) setUserFlag(ctx context.Context, username, column string, val bool) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO users (username, %[1]s) VALUES (?, ?)
+		ON CONFLICT (username) DO UPDATE SET %[1]s = excluded.%[1]s`, column), username, val)
+	if s.verbose {
+		log.Printf("set %s for %q to %t -> %v", column, username, val, err)
+	}
+	return err
+}
+
+func (s *SQLiteDB) GetUserSkip(ctx context.Context, username string) (bool, error) {
+	return s.getUserFlag(ctx, username, "skip")
+}
+
+func (s *SQLiteDB) SetUserSkip(ctx context.Context, username string, skip bool) error {
+	return s.setUserFlag(ctx, username, "skip", skip)
+}
+
+func (s *SQLiteDB) GetUserFollowersDone(ctx context.Context, username string) (bool, error) {
+	return s.getUserFlag(ctx, username, "followers_done")
+}
+
+func (s *SQLiteDB) SetUserFollowersDone(ctx context.Context, username string, done bool) error {
+	return s.setUserFlag(ctx, username, "followers_done", done)
+}
+
+func (s *SQLiteDB) GetUserFollowingDone(ctx context.Context, username string) (bool, error) {
+	return s.getUserFlag(ctx, username, "following_done")
+}
+
+func (s *SQLiteDB) SetUserFollowingDone(ctx context.Context, username string, done bool) error {
+	return s.setUserFlag(ctx, username, "following_done", done)
+}
+
+func (s *SQLiteDB) SetFollowers(ctx context.Context, username string, offset int, followers []string) error {
+	return s.setFollowish(ctx, "followers", username, offset, followers)
+}
+
+func (s *SQLiteDB) SetFollowing(ctx context.Context, username string, offset int, following []string) error {
+	return s.setFollowish(ctx, "following", username, offset, following)
+}
+
+func (s *SQLiteDB) setFollowish(ctx context.Context, table, username string, offset int, usernames []string) error {
+	b, err := json.Marshal(usernames)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (username, shard_offset, usernames) VALUES (?, ?, ?)
+		ON CONFLICT (username, shard_offset) DO UPDATE SET usernames = excluded.usernames`, table), username, offset, string(b))
+	if s.verbose {
+		log.Printf("setFollowish[%q](%q, %d) -> %v", table, username, offset, err)
+	}
+	return err
+}
+
+func (s *SQLiteDB) GetFollowers(ctx context.Context, username string) (chan string, chan error, error) {
+	return s.getFollowish(ctx, "followers", username)
+}
+
+func (s *SQLiteDB) GetFollowing(ctx context.Context, username string) (chan string, chan error, error) {
+	return s.getFollowish(ctx, "following", username)
+}
+
+// getFollowish reads every shard before streaming them, so that slow readers don't hold the connection.
+func (s *SQLiteDB) getFollowish(ctx context.Context, table, username string) (chan string, chan error, error) {
+	shards, err := s.getFollowishShards(ctx, table, username)
+	if err != nil {
+		return nil, nil, err
+	}
+	res := make(chan string)
+	errs := make(chan error)
+	go func() {
+		defer close(res)
+		defer close(errs)
+		for _, shard := range shards {
+			for _, u := range shard.Strings {
+				select {
+				case res <- u:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return res, errs, nil
+}
+
+func (s *SQLiteDB) GetFollowersShards(ctx context.Context, username string) ([]api.OffsetStrings, error) {
+	return s.getFollowishShards(ctx, "followers", username)
+}
+
+func (s *SQLiteDB) GetFollowingShards(ctx context.Context, username string) ([]api.OffsetStrings, error) {
+	return s.getFollowishShards(ctx, "following", username)
+}
+
+func (s *SQLiteDB) getFollowishShards(ctx context.Context, table, username string) ([]api.OffsetStrings, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT shard_offset, usernames FROM %s WHERE username = ? ORDER BY shard_offset", table), username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []api.OffsetStrings
+	for rows.Next() {
+		var offset int
+		var b string
+		if err := rows.Scan(&offset, &b); err != nil {
+			return nil, err
+		}
+		var usernames []string
+		if err := json.Unmarshal([]byte(b), &usernames); err != nil {
+			return nil, err
+		}
+		res = append(res, api.OffsetStrings{Offset: offset, Strings: usernames})
+	}
+	return res, rows.Err()
+}
+
+func (s *SQLiteDB) GetUserMaxFollowerOffset(ctx context.Context, username string) (int, error) {
+	return s.maxOffset(ctx, "followers", username)
+}
+
+func (s *SQLiteDB) GetUserMaxFollowingOffset(ctx context.Context, username string) (int, error) {
+	return s.maxOffset(ctx, "following", username)
+}
+
+func (s *SQLiteDB) maxOffset(ctx context.Context, table, username string) (int, error) {
+	var res int
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COALESCE(MAX(shard_offset), 0) FROM %s WHERE username = ?", table), username).Scan(&res)
+	return res, err
+}
+
+func (s *SQLiteDB) DeleteFollowers(ctx context.Context, username string) error {
+	return s.deleteFollowish(ctx, "followers", "followers_done", username)
+}
+
+func (s *SQLiteDB) DeleteFollowing(ctx context.Context, username string) error {
+	return s.deleteFollowish(ctx, "following", "following_done", username)
+}
+
+func (s *SQLiteDB) deleteFollowish(ctx context.Context, table, doneColumn, username string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE username = ?", table), username); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE users SET %s = 0 WHERE username = ?", doneColumn), username); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteDB) AddPostInfos(ctx context.Context, username string, postInfos []api.PostInfo) (UpsertCounts, error) {
+	var res UpsertCounts
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return res, err
+	}
+	seen := map[string]bool{}
+	for _, p := range postInfos {
+		b, err := json.Marshal(p)
+		if err != nil {
+			tx.Rollback()
+			return UpsertCounts{}, err
+		}
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM posts WHERE id = ?)", p.ID).Scan(&exists); err != nil {
+			tx.Rollback()
+			return UpsertCounts{}, err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO posts (id, username, cdate, post_info) VALUES (?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET username = excluded.username, cdate = excluded.cdate, post_info = excluded.post_info`,
+			p.ID, username, int64(p.CDate), string(b)); err != nil {
+			tx.Rollback()
+			return UpsertCounts{}, err
+		}
+		switch {
+		case seen[p.ID]:
+		case exists:
+			res.Updated++
+		default:
+			res.Inserted++
+		}
+		seen[p.ID] = true
+	}
+	if err := tx.Commit(); err != nil {
+		return UpsertCounts{}, err
+	}
+	if s.verbose {
+		log.Printf("AddPostInfos(%q, %d posts) -> %+v", username, len(postInfos), res)
+	}
+	return res, nil
+}
+
+func (s *SQLiteDB) GetPostInfos(ctx context.Context, username string) ([]api.PostInfo, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT post_info FROM posts WHERE username = ? ORDER BY cdate DESC", username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []api.PostInfo
+	for rows.Next() {
+		var b string
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		var p api.PostInfo
+		if err := json.Unmarshal([]byte(b), &p); err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+func (s *SQLiteDB) GetLatestPostCDate(ctx context.Context, username string) (api.IntDate, error) {
+	var res sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, "SELECT MAX(cdate) FROM posts WHERE username = ?", username).Scan(&res); err != nil {
+		return 0, err
+	}
+	return api.IntDate(res.Int64), nil
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"context"
+	"path"
+	"testing"
+)
+
+func TestSQLiteDB(t *testing.T) {
+	db, err := MakeSQLiteDB(context.Background(), ":memory:")
+	if err != nil {
+		t.Fatalf("MakeSQLiteDB: %v", err)
+	}
+	defer db.Close()
+	testStore(t, db)
+}
+
+func TestSQLiteDBReopen(t *testing.T) {
+	ctx := context.Background()
+	file := path.Join(t.TempDir(), "gettr.db")
+
+	db, err := MakeSQLiteDB(ctx, file)
+	if err != nil {
+		t.Fatalf("MakeSQLiteDB: %v", err)
+	}
+	if err := db.SetUserFollowersDone(ctx, testUsername, true); err != nil {
+		t.Fatalf("SetUserFollowersDone: %v", err)
+	}
+	db.Close()
+
+	// Migrations that were already applied must not be applied again.
+	db, err = MakeSQLiteDB(ctx, file)
+	if err != nil {
+		t.Fatalf("MakeSQLiteDB: %v", err)
+	}
+	defer db.Close()
+	if got, err := db.GetUserFollowersDone(ctx, testUsername); err != nil {
+		t.Fatalf("GetUserFollowersDone: %v", err)
+	} else if !got {
+		t.Errorf("GetUserFollowersDone: want true after reopening")
+	}
+}
@@ -0,0 +1,306 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/log"
+	"github.com/thomaso-mirodin/intmath/intgr"
+)
+
+// Store persists what we've read about users so that it needn't be read from the API again. Followers and
+// following are stored in shards keyed by the offset they were read from, so that a partial read can be
+// resumed from the largest offset.
+type Store interface {
+	// GetUserInfo returns nil if no info has been stored for username.
+	GetUserInfo(ctx context.Context, username string) (*api.UserInfo, error)
+	SetUserInfo(ctx context.Context, username string, userInfo api.UserInfo) error
+	GetUserSkip(ctx context.Context, username string) (bool, error)
+	SetUserSkip(ctx context.Context, username string, skip bool) error
+	GetUserFollowersDone(ctx context.Context, username string) (bool, error)
+	SetUserFollowersDone(ctx context.Context, username string, done bool) error
+	GetUserFollowingDone(ctx context.Context, username string) (bool, error)
+	SetUserFollowingDone(ctx context.Context, username string, done bool) error
+
+	SetFollowers(ctx context.Context, username string, offset int, followers []string) error
+	SetFollowing(ctx context.Context, username string, offset int, following []string) error
+	GetFollowers(ctx context.Context, username string) (chan string, chan error, error)
+	GetFollowing(ctx context.Context, username string) (chan string, chan error, error)
+	// GetFollowersShards returns the shards of username's followers in order of offset.
+	GetFollowersShards(ctx context.Context, username string) ([]api.OffsetStrings, error)
+	// GetFollowingShards returns the shards of username's following in order of offset.
+	GetFollowingShards(ctx context.Context, username string) ([]api.OffsetStrings, error)
+	GetUserMaxFollowerOffset(ctx context.Context, username string) (int, error)
+	GetUserMaxFollowingOffset(ctx context.Context, username string) (int, error)
+	// DeleteFollowers deletes every shard of username's followers and clears their done marker.
+	DeleteFollowers(ctx context.Context, username string) error
+	// DeleteFollowing deletes every shard of username's following and clears their done marker.
+	DeleteFollowing(ctx context.Context, username string) error
+
+	// AddPostInfos stores postInfos for username, replacing any post already stored with the same ID.
+	AddPostInfos(ctx context.Context, username string, postInfos []api.PostInfo) (UpsertCounts, error)
+	GetPostInfos(ctx context.Context, username string) ([]api.PostInfo, error)
+	// GetLatestPostCDate returns when the newest stored post of username was created, or zero if none are.
+	GetLatestPostCDate(ctx context.Context, username string) (api.IntDate, error)
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*SQLiteDB)(nil)
+	_ Store = (*cacheStore)(nil)
+)
+
+const cacheKeyPosts cacheKey = "posts"
+
+// cacheStore is a Store on top of a Cache. Each user's data is under users/<username>.
+type cacheStore struct {
+	cache Cache
+}
+
+// MakeCacheStore returns a Store that keeps everything in cache.
+func MakeCacheStore(cache Cache) Store {
+	return &cacheStore{cache: cache}
+}
+
+func (c *cacheStore) parts(username string, key cacheKey, rest ...string) []string {
+	return append([]string{"users", username, string(key)}, rest...)
+}
+
+func (c *cacheStore) has(username string, key cacheKey) (bool, error) {
+	return c.cache.Has(c.parts(username, key)...)
+}
+
+func (c *cacheStore) setMarker(username string, key cacheKey, val bool) error {
+	if val {
+		return c.cache.Set(c.parts(username, key)...)
+	}
+	return c.cache.Delete(c.parts(username, key)...)
+}
+
+func (c *cacheStore) GetUserInfo(ctx context.Context, username string) (*api.UserInfo, error) {
+	bytes, err := c.cache.GetBytes(c.parts(username, cacheKeyUserInfo)...)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes) == 0 {
+		return nil, nil
+	}
+	var res api.UserInfo
+	if err := json.Unmarshal(bytes, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *cacheStore) SetUserInfo(ctx context.Context, username string, userInfo api.UserInfo) error {
+	return c.cache.SetGeneric(userInfo, c.parts(username, cacheKeyUserInfo)...)
+}
+
+func (c *cacheStore) GetUserSkip(ctx context.Context, username string) (bool, error) {
+	return c.has(username, cacheKeySkipUserInfo)
+}
+
+func (c *cacheStore) SetUserSkip(ctx context.Context, username string, skip bool) error {
+	return c.setMarker(username, cacheKeySkipUserInfo, skip)
+}
+
+func (c *cacheStore) GetUserFollowersDone(ctx context.Context, username string) (bool, error) {
+	return c.has(username, cacheKeyFollowersDone)
+}
+
+func (c *cacheStore) SetUserFollowersDone(ctx context.Context, username string, done bool) error {
+	return c.setMarker(username, cacheKeyFollowersDone, done)
+}
+
+func (c *cacheStore) GetUserFollowingDone(ctx context.Context, username string) (bool, error) {
+	return c.has(username, cacheKeyFollowingDone)
+}
+
+func (c *cacheStore) SetUserFollowingDone(ctx context.Context, username string, done bool) error {
+	return c.setMarker(username, cacheKeyFollowingDone, done)
+}
+
+func (c *cacheStore) SetFollowers(ctx context.Context, username string, offset int, followers []string) error {
+	return c.cache.SetGeneric(followers, c.parts(username, cacheKeyFollowersByOffset, strconv.Itoa(offset))...)
+}
+
+func (c *cacheStore) SetFollowing(ctx context.Context, username string, offset int, following []string) error {
+	return c.cache.SetGeneric(following, c.parts(username, cacheKeyFollowingByOffset, strconv.Itoa(offset))...)
+}
+
+func (c *cacheStore) GetFollowers(ctx context.Context, username string) (chan string, chan error, error) {
+	return c.getFollowish(ctx, username, cacheKeyFollowersByOffset, cacheKeyFollowers)
+}
+
+func (c *cacheStore) GetFollowing(ctx context.Context, username string) (chan string, chan error, error) {
+	return c.getFollowish(ctx, username, cacheKeyFollowingByOffset, cacheKeyFollowing)
+}
+
+// getFollowish reads the shards under byOffset or, if there are none, the unsharded list under legacy that
+// older versions wrote.
+func (c *cacheStore) getFollowish(ctx context.Context, username string, byOffset, legacy cacheKey) (chan string, chan error, error) {
+	var usernames []string
+	ss, err := c.cache.GetAllStrings(c.parts(username, byOffset)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	usernames = ss.Strings()
+	if len(usernames) == 0 {
+		if ok, err := c.has(username, legacy); err != nil {
+			return nil, nil, err
+		} else if ok {
+			if usernames, err = c.cache.GetStrings(c.parts(username, legacy)...); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	res := make(chan string)
+	errs := make(chan error)
+	go func() {
+		defer close(res)
+		defer close(errs)
+		for _, u := range usernames {
+			select {
+			case res <- u:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return res, errs, nil
+}
+
+func (c *cacheStore) GetFollowersShards(ctx context.Context, username string) ([]api.OffsetStrings, error) {
+	return c.getFollowishShards(username, cacheKeyFollowersByOffset)
+}
+
+func (c *cacheStore) GetFollowingShards(ctx context.Context, username string) ([]api.OffsetStrings, error) {
+	return c.getFollowishShards(username, cacheKeyFollowingByOffset)
+}
+
+func (c *cacheStore) getFollowishShards(username string, key cacheKey) ([]api.OffsetStrings, error) {
+	ss, err := c.cache.GetAllStrings(c.parts(username, key)...)
+	if err != nil {
+		return nil, err
+	}
+	byOffset := map[int][]string{}
+	for _, s := range ss {
+		offset, err := strconv.Atoi(s.Dir)
+		if err != nil {
+			log.Printf("ignoring Atoi: %v", err)
+			continue
+		}
+		byOffset[offset] = append(byOffset[offset], s.Val)
+	}
+	var res []api.OffsetStrings
+	for offset, usernames := range byOffset {
+		res = append(res, api.OffsetStrings{Offset: offset, Strings: usernames})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Offset < res[j].Offset })
+	return res, nil
+}
+
+func (c *cacheStore) GetUserMaxFollowerOffset(ctx context.Context, username string) (int, error) {
+	return c.maxOffset(username, cacheKeyFollowersByOffset)
+}
+
+func (c *cacheStore) GetUserMaxFollowingOffset(ctx context.Context, username string) (int, error) {
+	return c.maxOffset(username, cacheKeyFollowingByOffset)
+}
+
+func (c *cacheStore) maxOffset(username string, key cacheKey) (int, error) {
+	if ok, err := c.has(username, key); err != nil || !ok {
+		return 0, err
+	}
+	keys, err := c.cache.FindKeys(c.parts(username, key)...)
+	if err != nil {
+		return 0, err
+	}
+	var res int
+	for _, k := range keys {
+		n, err := strconv.Atoi(k)
+		if err != nil {
+			log.Printf("ignoring Atoi: %v", err)
+			continue
+		}
+		res = intgr.Max(res, n)
+	}
+	return res, nil
+}
+
+func (c *cacheStore) DeleteFollowers(ctx context.Context, username string) error {
+	for _, key := range []cacheKey{cacheKeyFollowersByOffset, cacheKeyFollowers, cacheKeyFollowersDone} {
+		if err := c.cache.Delete(c.parts(username, key)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *cacheStore) DeleteFollowing(ctx context.Context, username string) error {
+	for _, key := range []cacheKey{cacheKeyFollowingByOffset, cacheKeyFollowing, cacheKeyFollowingDone} {
+		if err := c.cache.Delete(c.parts(username, key)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *cacheStore) AddPostInfos(ctx context.Context, username string, postInfos []api.PostInfo) (UpsertCounts, error) {
+	var res UpsertCounts
+	seen := map[string]bool{}
+	for _, p := range postInfos {
+		parts := c.parts(username, cacheKeyPosts, p.ID)
+		exists, err := c.cache.Has(parts...)
+		if err != nil {
+			return res, err
+		}
+		if err := c.cache.SetGeneric(p, parts...); err != nil {
+			return res, err
+		}
+		switch {
+		case seen[p.ID]:
+		case exists:
+			res.Updated++
+		default:
+			res.Inserted++
+		}
+		seen[p.ID] = true
+	}
+	return res, nil
+}
+
+func (c *cacheStore) GetPostInfos(ctx context.Context, username string) ([]api.PostInfo, error) {
+	if ok, err := c.has(username, cacheKeyPosts); err != nil || !ok {
+		return nil, err
+	}
+	ids, err := c.cache.FindKeys(c.parts(username, cacheKeyPosts)...)
+	if err != nil {
+		return nil, err
+	}
+	var res []api.PostInfo
+	for _, id := range ids {
+		bytes, err := c.cache.GetBytes(c.parts(username, cacheKeyPosts, id)...)
+		if err != nil {
+			return nil, err
+		}
+		var p api.PostInfo
+		if err := json.Unmarshal(bytes, &p); err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].CDate > res[j].CDate })
+	return res, nil
+}
+
+func (c *cacheStore) GetLatestPostCDate(ctx context.Context, username string) (api.IntDate, error) {
+	posts, err := c.GetPostInfos(ctx, username)
+	if err != nil || len(posts) == 0 {
+		return 0, err
+	}
+	return posts[0].CDate, nil
+}
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/spudtrooper/gettr/api"
+)
+
+func readFollowers(ctx context.Context, t *testing.T, store Store, username string) []string {
+	t.Helper()
+	usernames, errs, err := store.GetFollowers(ctx, username)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	var res []string
+	for u := range usernames {
+		res = append(res, u)
+	}
+	for err := range errs {
+		t.Errorf("get: %v", err)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func TestCacheStore(t *testing.T) {
+	testStore(t, MakeCacheStore(makeCache(t.TempDir())))
+}
+
+// testStore checks the behavior every Store must have.
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	username := testUsername
+
+	if got, err := store.GetUserInfo(ctx, username); err != nil {
+		t.Fatalf("GetUserInfo: %v", err)
+	} else if got != nil {
+		t.Errorf("GetUserInfo: want nil, got %v", got)
+	}
+	userInfo := api.UserInfo{Username: username, OUsername: username, Lang: "en"}
+	if err := store.SetUserInfo(ctx, username, userInfo); err != nil {
+		t.Fatalf("SetUserInfo: %v", err)
+	}
+	if got, err := store.GetUserInfo(ctx, username); err != nil {
+		t.Fatalf("GetUserInfo: %v", err)
+	} else if !reflect.DeepEqual(*got, userInfo) {
+		t.Errorf("GetUserInfo: want != got: %v %v", userInfo, *got)
+	}
+
+	if err := store.SetFollowers(ctx, username, 0, []string{"a", "b"}); err != nil {
+		t.Fatalf("SetFollowers: %v", err)
+	}
+	if err := store.SetFollowers(ctx, username, 20, []string{"c"}); err != nil {
+		t.Fatalf("SetFollowers: %v", err)
+	}
+	if err := store.SetUserFollowersDone(ctx, username, true); err != nil {
+		t.Fatalf("SetUserFollowersDone: %v", err)
+	}
+
+	if got, want := readFollowers(ctx, t, store, username), []string{"a", "b", "c"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("GetFollowers: want != got: %v %v", want, got)
+	}
+	if got, err := store.GetUserMaxFollowerOffset(ctx, username); err != nil {
+		t.Fatalf("GetUserMaxFollowerOffset: %v", err)
+	} else if want := 20; got != want {
+		t.Errorf("GetUserMaxFollowerOffset: got != want: %v != %v", got, want)
+	}
+	if got, err := store.GetFollowersShards(ctx, username); err != nil {
+		t.Fatalf("GetFollowersShards: %v", err)
+	} else {
+		for _, s := range got {
+			sort.Strings(s.Strings)
+		}
+		if want := []api.OffsetStrings{{Offset: 0, Strings: []string{"a", "b"}}, {Offset: 20, Strings: []string{"c"}}}; !reflect.DeepEqual(want, got) {
+			t.Errorf("GetFollowersShards: want != got: %v %v", want, got)
+		}
+	}
+
+	if err := store.DeleteFollowers(ctx, username); err != nil {
+		t.Fatalf("DeleteFollowers: %v", err)
+	}
+	if got, err := store.GetUserFollowersDone(ctx, username); err != nil {
+		t.Fatalf("GetUserFollowersDone: %v", err)
+	} else if got {
+		t.Errorf("GetUserFollowersDone: want false after DeleteFollowers")
+	}
+	if got := readFollowers(ctx, t, store, username); len(got) != 0 {
+		t.Errorf("GetFollowers: want none after DeleteFollowers, got %v", got)
+	}
+
+	if got, err := store.GetLatestPostCDate(ctx, username); err != nil {
+		t.Fatalf("GetLatestPostCDate: %v", err)
+	} else if got != 0 {
+		t.Errorf("GetLatestPostCDate: want 0 without posts, got %v", got)
+	}
+	if got, err := store.AddPostInfos(ctx, username, []api.PostInfo{{ID: "p1", CDate: 1}, {ID: "p2", CDate: 2}, {ID: "p1", CDate: 1}}); err != nil {
+		t.Fatalf("AddPostInfos: %v", err)
+	} else if want := (UpsertCounts{Inserted: 2}); got != want {
+		t.Errorf("AddPostInfos: got != want: %+v != %+v", got, want)
+	}
+	if got, err := store.AddPostInfos(ctx, username, []api.PostInfo{{ID: "p2", CDate: 2}}); err != nil {
+		t.Fatalf("AddPostInfos: %v", err)
+	} else if want := (UpsertCounts{Updated: 1}); got != want {
+		t.Errorf("AddPostInfos: got != want: %+v != %+v", got, want)
+	}
+	if got, err := store.GetPostInfos(ctx, username); err != nil {
+		t.Fatalf("GetPostInfos: %v", err)
+	} else if want := []api.PostInfo{{ID: "p2", CDate: 2}, {ID: "p1", CDate: 1}}; !reflect.DeepEqual(want, got) {
+		t.Errorf("GetPostInfos: want != got: %v %v", want, got)
+	}
+	if got, err := store.GetLatestPostCDate(ctx, username); err != nil {
+		t.Fatalf("GetLatestPostCDate: %v", err)
+	} else if got != 2 {
+		t.Errorf("GetLatestPostCDate: want 2, got %v", got)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, MakeMemoryStore())
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/api/fakegettr"
+)
+
+func makeTestUsers(prefix string, n int) []string {
+	var res []string
+	for i := 0; i < n; i++ {
+		res = append(res, fmt.Sprintf("%s%03d", prefix, i))
+	}
+	return res
+}
+
+func usernames(users chan *User, errs chan error) ([]string, error) {
+	us, err := collectUsers(users, errs)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, u := range us {
+		res = append(res, u.Username())
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func TestUserFollowers(t *testing.T) {
+	ctx := context.Background()
+	followers := makeTestUsers("follower", 45)
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Users:     []api.UserInfo{{Username: "other", OUsername: "other"}},
+		Followers: map[string][]string{"other": followers},
+	})
+	defer s.Close()
+	f := MakeMemoryFactory(s.MakeClient("me"))
+
+	u := f.MakeUser("other")
+	if got, err := usernames(u.Followers(ctx, UserFollowersMax(20))); err != nil {
+		t.Fatalf("Followers: %v", err)
+	} else if !reflect.DeepEqual(followers, got) {
+		t.Errorf("Followers: want != got: %v %v", followers, got)
+	}
+	if done, err := f.Store().GetUserFollowersDone(ctx, "other"); err != nil {
+		t.Fatalf("GetUserFollowersDone: %v", err)
+	} else if !done {
+		t.Errorf("GetUserFollowersDone: want true after reading every follower")
+	}
+
+	// The second time they come from the store.
+	before := len(s.Requests())
+	if got, err := usernames(u.Followers(ctx, UserFollowersMax(20))); err != nil {
+		t.Fatalf("Followers: %v", err)
+	} else if !reflect.DeepEqual(followers, got) {
+		t.Errorf("Followers: want != got: %v %v", followers, got)
+	}
+	if got := len(s.Requests()) - before; got != 0 {
+		t.Errorf("Followers: want no requests when stored, got %d", got)
+	}
+}
+
+func TestUserFollowingResumes(t *testing.T) {
+	ctx := context.Background()
+	following := makeTestUsers("following", 50)
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Users:     []api.UserInfo{{Username: "other", OUsername: "other"}},
+		Following: map[string][]string{"other": following},
+	})
+	defer s.Close()
+	f := MakeMemoryFactory(s.MakeClient("me"))
+
+	// Pretend that an earlier read stopped after the first two pages.
+	if err := f.Store().SetFollowing(ctx, "other", 0, following[:20]); err != nil {
+		t.Fatalf("SetFollowing: %v", err)
+	}
+	if err := f.Store().SetFollowing(ctx, "other", 20, following[20:40]); err != nil {
+		t.Fatalf("SetFollowing: %v", err)
+	}
+
+	u := f.MakeUser("other")
+	if got, err := usernames(u.Following(ctx, UserFollowingMax(20))); err != nil {
+		t.Fatalf("Following: %v", err)
+	} else if want := following[20:]; !reflect.DeepEqual(want, got) {
+		t.Errorf("Following: want != got: %v %v", want, got)
+	}
+	shards, err := f.Store().GetFollowingShards(ctx, "other")
+	if err != nil {
+		t.Fatalf("GetFollowingShards: %v", err)
+	}
+	var offsets []int
+	for _, s := range shards {
+		offsets = append(offsets, s.Offset)
+	}
+	if want := []int{0, 20, 40}; !reflect.DeepEqual(want, offsets) {
+		t.Errorf("GetFollowingShards: want offsets != got: %v %v", want, offsets)
+	}
+}
+
+func TestUserPersist(t *testing.T) {
+	ctx := context.Background()
+	followers := makeTestUsers("follower", 5)
+	following := makeTestUsers("following", 3)
+	s := fakegettr.Make(&fakegettr.Fixtures{
+		Users:     []api.UserInfo{{Username: "other", OUsername: "other"}},
+		Followers: map[string][]string{"other": followers},
+		Following: map[string][]string{"other": following},
+	})
+	defer s.Close()
+	f := MakeMemoryFactory(s.MakeClient("me"))
+
+	if err := f.MakeUser("other").Persist(ctx); err != nil {
+		t.Fatalf("Persist: %v", err)
+	}
+
+	for _, username := range append(append([]string{"other"}, followers...), following...) {
+		userInfo, err := f.Store().GetUserInfo(ctx, username)
+		if err != nil {
+			t.Fatalf("GetUserInfo(%q): %v", username, err)
+		}
+		if userInfo == nil || userInfo.Username != username {
+			t.Errorf("GetUserInfo(%q): want it stored, got %v", username, userInfo)
+		}
+	}
+	for _, done := range []func(Store, context.Context, string) (bool, error){Store.GetUserFollowersDone, Store.GetUserFollowingDone} {
+		if got, err := done(f.Store(), ctx, "other"); err != nil {
+			t.Fatalf("done: %v", err)
+		} else if !got {
+			t.Errorf("done: want true after Persist")
+		}
+	}
+}
@@ -1,7 +1,7 @@
 // DO NOT EDIT MANUALLY: Generated from https://github.com/spudtrooper/genopts
 package model
 
-//go:generate genopts --prefix=UserFollowers --outfile=userfollowersoptions.go "offset:int" "max:int" "incl:[]string" "start:int" "threads:int" "fromDisk" "force"
+//go:generate genopts --prefix=UserFollowers --outfile=userfollowersoptions.go "offset:int" "max:int" "incl:[]string" "start:int" "threads:int" "fromDisk" "force" "storedOnly"
 
 type UserFollowersOption func(*userFollowersOptionImpl)
 
@@ -13,6 +13,7 @@ type UserFollowersOptions interface {
 	Threads() int
 	FromDisk() bool
 	Force() bool
+	StoredOnly() bool
 }
 
 func UserFollowersOffset(offset int) UserFollowersOption {
@@ -92,23 +93,36 @@ func UserFollowersForceFlag(force *bool) UserFollowersOption {
 	}
 }
 
+func UserFollowersStoredOnly(storedOnly bool) UserFollowersOption {
+	return func(opts *userFollowersOptionImpl) {
+		opts.storedOnly = storedOnly
+	}
+}
+func UserFollowersStoredOnlyFlag(storedOnly *bool) UserFollowersOption {
+	return func(opts *userFollowersOptionImpl) {
+		opts.storedOnly = *storedOnly
+	}
+}
+
 type userFollowersOptionImpl struct {
-	offset   int
-	max      int
-	incl     []string
-	start    int
-	threads  int
-	fromDisk bool
-	force    bool
+	offset     int
+	max        int
+	incl       []string
+	start      int
+	threads    int
+	fromDisk   bool
+	force      bool
+	storedOnly bool
 }
 
-func (u *userFollowersOptionImpl) Offset() int    { return u.offset }
-func (u *userFollowersOptionImpl) Max() int       { return u.max }
-func (u *userFollowersOptionImpl) Incl() []string { return u.incl }
-func (u *userFollowersOptionImpl) Start() int     { return u.start }
-func (u *userFollowersOptionImpl) Threads() int   { return u.threads }
-func (u *userFollowersOptionImpl) FromDisk() bool { return u.fromDisk }
-func (u *userFollowersOptionImpl) Force() bool    { return u.force }
+func (u *userFollowersOptionImpl) Offset() int      { return u.offset }
+func (u *userFollowersOptionImpl) Max() int         { return u.max }
+func (u *userFollowersOptionImpl) Incl() []string   { return u.incl }
+func (u *userFollowersOptionImpl) Start() int       { return u.start }
+func (u *userFollowersOptionImpl) Threads() int     { return u.threads }
+func (u *userFollowersOptionImpl) FromDisk() bool   { return u.fromDisk }
+func (u *userFollowersOptionImpl) Force() bool      { return u.force }
+func (u *userFollowersOptionImpl) StoredOnly() bool { return u.storedOnly }
 
 func makeUserFollowersOptionImpl(opts ...UserFollowersOption) *userFollowersOptionImpl {
 	res := &userFollowersOptionImpl{}
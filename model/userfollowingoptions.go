@@ -1,7 +1,7 @@
 // DO NOT EDIT MANUALLY: Generated from https://github.com/spudtrooper/genopts
 package model
 
-//go:generate genopts --prefix=UserFollowing --outfile=userfollowingoptions.go "offset:int" "max:int" "incl:[]string" "start:int" "threads:int" "fromDisk" "force"
+//go:generate genopts --prefix=UserFollowing --outfile=userfollowingoptions.go "offset:int" "max:int" "incl:[]string" "start:int" "threads:int" "fromDisk" "force" "storedOnly"
 
 type UserFollowingOption func(*userFollowingOptionImpl)
 
@@ -13,6 +13,7 @@ type UserFollowingOptions interface {
 	Threads() int
 	FromDisk() bool
 	Force() bool
+	StoredOnly() bool
 }
 
 func UserFollowingOffset(offset int) UserFollowingOption {
@@ -92,23 +93,36 @@ func UserFollowingForceFlag(force *bool) UserFollowingOption {
 	}
 }
 
+func UserFollowingStoredOnly(storedOnly bool) UserFollowingOption {
+	return func(opts *userFollowingOptionImpl) {
+		opts.storedOnly = storedOnly
+	}
+}
+func UserFollowingStoredOnlyFlag(storedOnly *bool) UserFollowingOption {
+	return func(opts *userFollowingOptionImpl) {
+		opts.storedOnly = *storedOnly
+	}
+}
+
 type userFollowingOptionImpl struct {
-	offset   int
-	max      int
-	incl     []string
-	start    int
-	threads  int
-	fromDisk bool
-	force    bool
+	offset     int
+	max        int
+	incl       []string
+	start      int
+	threads    int
+	fromDisk   bool
+	force      bool
+	storedOnly bool
 }
 
-func (u *userFollowingOptionImpl) Offset() int    { return u.offset }
-func (u *userFollowingOptionImpl) Max() int       { return u.max }
-func (u *userFollowingOptionImpl) Incl() []string { return u.incl }
-func (u *userFollowingOptionImpl) Start() int     { return u.start }
-func (u *userFollowingOptionImpl) Threads() int   { return u.threads }
-func (u *userFollowingOptionImpl) FromDisk() bool { return u.fromDisk }
-func (u *userFollowingOptionImpl) Force() bool    { return u.force }
+func (u *userFollowingOptionImpl) Offset() int      { return u.offset }
+func (u *userFollowingOptionImpl) Max() int         { return u.max }
+func (u *userFollowingOptionImpl) Incl() []string   { return u.incl }
+func (u *userFollowingOptionImpl) Start() int       { return u.start }
+func (u *userFollowingOptionImpl) Threads() int     { return u.threads }
+func (u *userFollowingOptionImpl) FromDisk() bool   { return u.fromDisk }
+func (u *userFollowingOptionImpl) Force() bool      { return u.force }
+func (u *userFollowingOptionImpl) StoredOnly() bool { return u.storedOnly }
 
 func makeUserFollowingOptionImpl(opts ...UserFollowingOption) *userFollowingOptionImpl {
 	res := &userFollowingOptionImpl{}
@@ -1,12 +1,13 @@
 // DO NOT EDIT MANUALLY: Generated from https://github.com/spudtrooper/genopts
 package model
 
-//go:generate genopts --prefix=UserInfo --outfile=userinfooptions.go "dontRetry"
+//go:generate genopts --prefix=UserInfo --outfile=userinfooptions.go "dontRetry" "storedOnly"
 
 type UserInfoOption func(*userInfoOptionImpl)
 
 type UserInfoOptions interface {
 	DontRetry() bool
+	StoredOnly() bool
 }
 
 func UserInfoDontRetry(dontRetry bool) UserInfoOption {
@@ -20,11 +21,24 @@ func UserInfoDontRetryFlag(dontRetry *bool) UserInfoOption {
 	}
 }
 
+func UserInfoStoredOnly(storedOnly bool) UserInfoOption {
+	return func(opts *userInfoOptionImpl) {
+		opts.storedOnly = storedOnly
+	}
+}
+func UserInfoStoredOnlyFlag(storedOnly *bool) UserInfoOption {
+	return func(opts *userInfoOptionImpl) {
+		opts.storedOnly = *storedOnly
+	}
+}
+
 type userInfoOptionImpl struct {
-	dontRetry bool
+	dontRetry  bool
+	storedOnly bool
 }
 
-func (u *userInfoOptionImpl) DontRetry() bool { return u.dontRetry }
+func (u *userInfoOptionImpl) DontRetry() bool  { return u.dontRetry }
+func (u *userInfoOptionImpl) StoredOnly() bool { return u.storedOnly }
 
 func makeUserInfoOptionImpl(opts ...UserInfoOption) *userInfoOptionImpl {
 	res := &userInfoOptionImpl{}
@@ -0,0 +1,353 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/log"
+	"github.com/spudtrooper/goutil/or"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WatchTarget is a user checked by Watch every Interval.
+type WatchTarget struct {
+	Username string
+	Interval time.Duration
+}
+
+// ParseWatchTargets parses specs of the form username or username:interval, e.g. foo:30m.
+func ParseWatchTargets(specs []string, defaultInterval time.Duration) ([]WatchTarget, error) {
+	var res []WatchTarget
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		t := WatchTarget{Username: spec, Interval: defaultInterval}
+		if i := strings.Index(spec, ":"); i >= 0 {
+			d, err := time.ParseDuration(spec[i+1:])
+			if err != nil {
+				return nil, errors.Errorf("invalid interval in %q: %v", spec, err)
+			}
+			t = WatchTarget{Username: spec[:i], Interval: d}
+		}
+		if t.Interval <= 0 {
+			return nil, errors.Errorf("%s needs a positive interval", t.Username)
+		}
+		if seen[t.Username] {
+			return nil, errors.Errorf("%s is watched more than once", t.Username)
+		}
+		seen[t.Username] = true
+		res = append(res, t)
+	}
+	return res, nil
+}
+
+type WatchEventType string
+
+const (
+	WatchEventUserInfo  WatchEventType = "userinfo"
+	WatchEventPosts     WatchEventType = "posts"
+	WatchEventFollowers WatchEventType = "followers"
+	WatchEventError     WatchEventType = "error"
+)
+
+// WatchEvent is something Watch noticed about a user: their counts changed, they posted, they gained or lost
+// followers, or checking them failed.
+type WatchEvent struct {
+	Time     time.Time       `json:"time"`
+	Username string          `json:"username"`
+	Type     WatchEventType  `json:"type"`
+	UserInfo *UserInfoSample `json:"userInfo,omitempty"`
+	Posts    []string        `json:"posts,omitempty"`
+	Gained   []string        `json:"gained,omitempty"`
+	Lost     []string        `json:"lost,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// watchState is how far Watch got with a user, so that it resumes where it left off when restarted.
+type watchState struct {
+	Username            string
+	LastRun             time.Time
+	PostsWatermark      api.IntDate
+	FollowersSnapshotID string
+	// While a walk back to PostsWatermark is unfinished, PostsResumeOffset is where to pick it up and
+	// PostsResumeNewest and PostsResumeOldest are the newest and oldest posts it has read.
+	PostsResumeOffset int
+	PostsResumeNewest api.IntDate
+	PostsResumeOldest api.IntDate
+}
+
+func (d *DB) getWatchState(ctx context.Context, username string) (watchState, error) {
+	var res watchState
+	if err := d.collection("watchState").FindOne(ctx, bson.D{{"username", username}}).Decode(&res); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return watchState{Username: username}, nil
+		}
+		return watchState{}, errors.Errorf("watchState FindOne: %v", err)
+	}
+	return res, nil
+}
+
+func (d *DB) setWatchState(ctx context.Context, state watchState) error {
+	filter := bson.D{{"username", state.Username}}
+	if _, err := d.collection("watchState").ReplaceOne(ctx, filter, state, options.Replace().SetUpsert(true)); err != nil {
+		return errors.Errorf("watchState ReplaceOne: %v", err)
+	}
+	return nil
+}
+
+const watchPostsPageSize = 20
+
+// postsSince returns the posts of username newer than since, newest first from offset start, reading at most
+// maxPages pages of them. If it stopped before reaching since it also returns the offset to carry on from,
+// and otherwise zero. If since is zero only the first page is read.
+func postsSince(ctx context.Context, client *api.Extended, username string, since api.IntDate, start, maxPages int) ([]api.PostInfo, int, error) {
+	if since == 0 {
+		posts, err := client.GetPostsContext(ctx, username)
+		return posts, 0, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	posts, errs := client.AllPostsContext(ctx, username,
+		api.AllPostsSince(since),
+		api.AllPostsStart(start),
+		api.AllPostsMax(watchPostsPageSize))
+	var res []api.PostInfo
+	pages, next := 0, 0
+	for ps := range posts {
+		res = append(res, ps.Posts...)
+		if pages++; pages == maxPages {
+			// A page with fewer posts was the last, since it reached since or the end.
+			if len(ps.Posts) == watchPostsPageSize {
+				next = ps.Offset + watchPostsPageSize
+			}
+			cancel()
+			break
+		}
+	}
+	for err := range errs {
+		if ctx.Err() == nil {
+			return nil, 0, err
+		}
+	}
+	return res, next, nil
+}
+
+type watcher struct {
+	f      Factory
+	db     *DB
+	opts   WatchOptions
+	mu     sync.Mutex
+	emitFn func(WatchEvent)
+}
+
+func (w *watcher) emit(e WatchEvent) {
+	e.Time = time.Now()
+	// Events go out one at a time so that handlers needn't be thread-safe.
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.emitFn(e)
+}
+
+// Watch checks each target every target.Interval until ctx is done: it stores the user's info and emits an
+// event when their counts change, stores their new posts, and, with WatchFollowers, snapshots their followers
+// and emits who was gained and lost. How far it got with each user is kept in the DB, so a restarted Watch
+// picks up where the last one left off.
+func Watch(ctx context.Context, f Factory, targets []WatchTarget, wOpts ...WatchOption) error {
+	db := f.DB()
+	if db == nil {
+		return errors.Errorf("watching needs --store=mongo")
+	}
+	opts := MakeWatchOptions(wOpts...)
+	w := &watcher{f: f, db: db, opts: opts, emitFn: opts.OnEvent()}
+	if w.emitFn == nil {
+		w.emitFn = func(e WatchEvent) { log.Printf("watch event: %+v", e) }
+	}
+
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		t := t
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.watch(ctx, t)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (w *watcher) watch(ctx context.Context, t WatchTarget) {
+	for {
+		state, err := w.db.getWatchState(ctx, t.Username)
+		if err != nil {
+			w.emit(WatchEvent{Username: t.Username, Type: WatchEventError, Error: err.Error()})
+			state = watchState{Username: t.Username, LastRun: time.Now()}
+		}
+		if wait := time.Until(state.LastRun.Add(t.Interval)); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+		if err := w.check(ctx, &state); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			w.emit(WatchEvent{Username: t.Username, Type: WatchEventError, Error: err.Error()})
+		}
+		state.LastRun = time.Now()
+		if err := w.db.setWatchState(ctx, state); err != nil && ctx.Err() == nil {
+			w.emit(WatchEvent{Username: t.Username, Type: WatchEventError, Error: err.Error()})
+		}
+	}
+}
+
+// check looks at the user once, checkpointing state after each step.
+func (w *watcher) check(ctx context.Context, state *watchState) error {
+	username := state.Username
+	if err := w.checkUserInfo(ctx, username); err != nil {
+		return errors.Errorf("checking user info: %v", err)
+	}
+	if err := w.checkPosts(ctx, state); err != nil {
+		return errors.Errorf("checking posts: %v", err)
+	}
+	if err := w.db.setWatchState(ctx, *state); err != nil {
+		return err
+	}
+	if w.opts.Followers() {
+		if err := w.checkFollowers(ctx, state); err != nil {
+			return errors.Errorf("checking followers: %v", err)
+		}
+	}
+	return nil
+}
+
+func (w *watcher) checkUserInfo(ctx context.Context, username string) error {
+	prev, err := w.db.latestUserInfoSample(ctx, username, time.Time{})
+	if err != nil {
+		return err
+	}
+	userInfo, err := w.f.Client().GetUserInfoContext(ctx, username)
+	if err != nil {
+		return err
+	}
+	fetched := time.Now()
+	if err := w.db.SetUserInfo(ctx, username, userInfo); err != nil {
+		return err
+	}
+	if err := w.db.AddUserInfoSample(ctx, username, userInfo, fetched); err != nil {
+		return err
+	}
+	cur := makeUserInfoSample(username, userInfo, fetched)
+	if prev == nil || !prev.sameCounts(cur) {
+		w.emit(WatchEvent{Username: username, Type: WatchEventUserInfo, UserInfo: &cur})
+	}
+	return nil
+}
+
+// checkPosts stores the posts of state's user since PostsWatermark. A walk back to the watermark that takes
+// more than MaxPostPages pages is finished over the next checks, and only then is the watermark moved up to
+// the newest post read, so that no posts in between are missed.
+func (w *watcher) checkPosts(ctx context.Context, state *watchState) error {
+	start := state.PostsResumeOffset
+	posts, next, err := postsSince(ctx, w.f.Client(), state.Username, state.PostsWatermark, start, or.Int(w.opts.MaxPostPages(), 10))
+	if err != nil {
+		return err
+	}
+	if start > 0 {
+		// Posts since the last check push older ones to later offsets, so some of these were read last time.
+		var older []api.PostInfo
+		for _, p := range posts {
+			if p.CDate < state.PostsResumeOldest {
+				older = append(older, p)
+			}
+		}
+		posts = older
+	}
+	if len(posts) > 0 {
+		if _, err := w.db.AddPostInfos(ctx, state.Username, posts); err != nil {
+			return err
+		}
+	}
+	first := state.PostsWatermark == 0
+	newest, oldest := state.PostsResumeNewest, state.PostsResumeOldest
+	var ids []string
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+		if p.CDate > newest {
+			newest = p.CDate
+		}
+		if oldest == 0 || p.CDate < oldest {
+			oldest = p.CDate
+		}
+	}
+	if next == 0 {
+		if newest > state.PostsWatermark {
+			state.PostsWatermark = newest
+		}
+		state.PostsResumeOffset, state.PostsResumeNewest, state.PostsResumeOldest = 0, 0, 0
+	} else {
+		log.Printf("read %d pages of %s's posts without getting back to the last check; carrying on from %d next time", or.Int(w.opts.MaxPostPages(), 10), state.Username, next)
+		state.PostsResumeOffset, state.PostsResumeNewest, state.PostsResumeOldest = next, newest, oldest
+	}
+	// The first time there's nothing to compare to, so nothing is new.
+	if !first && len(ids) > 0 {
+		w.emit(WatchEvent{Username: state.Username, Type: WatchEventPosts, Posts: ids})
+	}
+	return nil
+}
+
+func (w *watcher) checkFollowers(ctx context.Context, state *watchState) error {
+	users, errs := w.f.MakeUser(state.Username).Followers(ctx,
+		UserFollowersForce(true),
+		UserFollowersThreads(w.opts.Threads()))
+	var firstErr error
+	done := make(chan bool)
+	go func() {
+		for err := range errs {
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		done <- true
+	}()
+	for range users {
+	}
+	<-done
+	if firstErr != nil {
+		return firstErr
+	}
+
+	snapshot, err := w.db.TakeSnapshot(ctx, state.Username, SnapshotFollowers)
+	if err != nil {
+		return err
+	}
+	prevID := state.FollowersSnapshotID
+	state.FollowersSnapshotID = snapshot.ID
+	if prevID == "" {
+		return w.db.setWatchState(ctx, *state)
+	}
+	diff, err := w.db.DiffSnapshots(ctx, prevID, snapshot.ID)
+	if err != nil {
+		return err
+	}
+	if len(diff.Gained) == 0 && len(diff.Lost) == 0 {
+		// Nothing changed so the older snapshot says nothing the new one doesn't.
+		if err := w.db.DeleteSnapshot(ctx, prevID); err != nil {
+			return errors.Errorf("deleting unchanged snapshot: %v", err)
+		}
+	} else {
+		w.emit(WatchEvent{Username: state.Username, Type: WatchEventFollowers, Gained: diff.Gained, Lost: diff.Lost})
+	}
+	return w.db.setWatchState(ctx, *state)
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/spudtrooper/gettr/api"
+	"github.com/spudtrooper/gettr/api/fakegettr"
+)
+
+func TestParseWatchTargets(t *testing.T) {
+	got, err := ParseWatchTargets([]string{"foo", " bar:15m ", ""}, time.Hour)
+	if err != nil {
+		t.Fatalf("ParseWatchTargets: %v", err)
+	}
+	want := []WatchTarget{{"foo", time.Hour}, {"bar", 15 * time.Minute}}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("ParseWatchTargets: want != got: %v %v", want, got)
+	}
+	for _, specs := range [][]string{{"foo:soon"}, {"foo:0s"}, {"foo", "foo:1m"}} {
+		if _, err := ParseWatchTargets(specs, time.Hour); err == nil {
+			t.Errorf("ParseWatchTargets(%v): want an error", specs)
+		}
+	}
+}
+
+func TestPostsSince(t *testing.T) {
+	ctx := context.Background()
+	// Newest first, like the real thing.
+	var posts []api.PostInfo
+	for i := 50; i > 0; i-- {
+		posts = append(posts, api.PostInfo{ID: fmt.Sprintf("p%02d", i), CDate: api.IntDate(i * 1000)})
+	}
+	s := fakegettr.Make(&fakegettr.Fixtures{Posts: map[string][]api.PostInfo{"other": posts}})
+	defer s.Close()
+	client := api.MakeExtended(s.MakeClient("me"))
+
+	ids := func(posts []api.PostInfo) []string {
+		var res []string
+		for _, p := range posts {
+			res = append(res, p.ID)
+		}
+		sort.Strings(res)
+		return res
+	}
+
+	if got, next, err := postsSince(ctx, client, "other", 45*1000, 0, 10); err != nil {
+		t.Fatalf("postsSince: %v", err)
+	} else if want := []string{"p46", "p47", "p48", "p49", "p50"}; !reflect.DeepEqual(want, ids(got)) {
+		t.Errorf("postsSince: want != got: %v %v", want, ids(got))
+	} else if next != 0 {
+		t.Errorf("postsSince: want no offset to carry on from, got %d", next)
+	}
+	// Without a watermark only the first page is read.
+	if got, next, err := postsSince(ctx, client, "other", 0, 0, 10); err != nil {
+		t.Fatalf("postsSince: %v", err)
+	} else if len(got) == 0 || len(got) == len(posts) || next != 0 {
+		t.Errorf("postsSince: want just the first page, got %d posts and offset %d", len(got), next)
+	}
+	// Stopping at maxPages gives the offset to carry on from until the watermark is reached.
+	var all []api.PostInfo
+	start, walks := 0, 0
+	for {
+		got, next, err := postsSince(ctx, client, "other", 5*1000, start, 1)
+		if err != nil {
+			t.Fatalf("postsSince: %v", err)
+		}
+		all = append(all, got...)
+		if walks++; next == 0 || walks > 5 {
+			break
+		}
+		start = next
+	}
+	if got, want := len(all), 45; got != want || walks != 3 {
+		t.Errorf("postsSince: want %d posts in 3 walks, got %d in %d", want, got, walks)
+	}
+}
@@ -2,6 +2,6 @@
 
 set -e
 
-go test api/*.go
-go test util/*.go
-go test model/*.go
\ No newline at end of file
+go test ./api/...
+go test ./util/...
+go test ./model/...